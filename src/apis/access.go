@@ -0,0 +1,90 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"src/util"
+	"strings"
+)
+
+// Headers a request proves who sent it with. A pair sends the token its
+// WebSocket connection was given, a director their secret. The connection
+// itself is opened with the secret the pair got when it registered.
+const (
+	SessionHeader  = "X-Session-Token"
+	DirectorHeader = "X-Director-Secret"
+)
+
+// The director whose secret the request carries. DIRECTORS names each
+// director's secret as name:secret pairs separated by commas, DIRECTOR_SECRET
+// alone is a single director called Director. Nobody is a director until a
+// secret has been configured.
+func directorName(r *http.Request) (string, bool) {
+	sent := r.Header.Get(DirectorHeader)
+	if sent == "" {
+		return "", false
+	}
+	directors := map[string]string{}
+	for _, entry := range strings.Split(os.Getenv("DIRECTORS"), ",") {
+		name, secret, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if ok && name != "" && secret != "" {
+			directors[name] = secret
+		}
+	}
+	if secret := os.Getenv("DIRECTOR_SECRET"); secret != "" {
+		directors["Director"] = secret
+	}
+	for name, secret := range directors {
+		if subtle.ConstantTimeCompare([]byte(sent), []byte(secret)) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func isDirector(r *http.Request) bool {
+	_, ok := directorName(r)
+	return ok
+}
+
+// Answers 401 unless the request carries the director secret, for the
+// endpoints only the director may use.
+func requireDirector(w http.ResponseWriter, r *http.Request) bool {
+	if !isDirector(r) {
+		http.Error(w, "The director secret is required", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// A secret for a registering pair to open its WebSocket connection with.
+// Only the hash is stored, so the event log never holds the secret itself.
+func newPairSecret() (string, string, error) {
+	secret, err := util.GenerateShortID(32)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, hashSecret(secret), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Pairs stored without a secret cannot connect at all.
+func pairSecretMatches(pair *Pair, secret string) bool {
+	return pair.SecretHash != "" && subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(pair.SecretHash)) == 1
+}
+
+// The pair whose live connection to the tournament the request's token belongs to.
+func requestingPair(h *Handler, r *http.Request, tournamentId string) (string, bool) {
+	token := r.Header.Get(SessionHeader)
+	if token == "" {
+		return "", false
+	}
+	return h.WebSocketHub.Client(tournamentId, token)
+}
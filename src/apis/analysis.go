@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"src/types"
+	"src/util/boards"
+	"src/util/dds"
+	"time"
+)
+
+func analyseDeal(deal types.Deal) (*types.Analysis, error) {
+	hands, err := dds.ParseDeal([4]string{deal.North, deal.East, deal.South, deal.West})
+	if err != nil {
+		return nil, err
+	}
+	info, err := boards.Get(deal.BoardNumber)
+	if err != nil {
+		return nil, err
+	}
+	tricks := dds.Solve(hands)
+	par, err := dds.CalculatePar(tricks, info.Vulnerability, deal.Dealer)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Analysis{Tricks: tricks, ParScore: par.Score, ParContract: par.Contract}, nil
+}
+
+func sameHands(a types.Deal, b types.Deal) bool {
+	return a.North == b.North && a.East == b.East && a.South == b.South && a.West == b.West
+}
+
+// Solves imported deals one board at a time. A board takes seconds, so this
+// runs after the import has been answered. A board whose hands are
+// imported again meanwhile is left to the later import.
+func analyseDeals(h *Handler, tournamentId string, deals []types.Deal) {
+	ctx := context.Background()
+	start := time.Now()
+	for _, deal := range deals {
+		analysis, err := analyseDeal(deal)
+		if err != nil {
+			fmt.Printf("Unable to analyse board %d: %v\n", deal.BoardNumber, err)
+			continue
+		}
+		current, err := getDeal(h, ctx, tournamentId, deal.BoardNumber)
+		if err != nil || current == nil || !sameHands(*current, deal) {
+			continue
+		}
+		current.Analysis = analysis
+		if err := h.Store.SetDeal(ctx, *current); err != nil {
+			fmt.Printf("Unable to store analysis of board %d: %v\n", deal.BoardNumber, err)
+		}
+	}
+	fmt.Println("Analysed", len(deals), "deals for tournament", tournamentId, "in", time.Since(start))
+
+	//results already scored get par beside them
+	tournament, err := GetTournamentById(h, ctx, tournamentId)
+	if err != nil || tournament.Type == TeamGame {
+		return
+	}
+	results, err := GetBoardResults(h, ctx, tournamentId)
+	if err != nil || len(results) == 0 {
+		return
+	}
+	if _, err := CalculateLeaderboard(h, ctx, results, *tournament, tournamentId); err != nil {
+		fmt.Println("Unable to add par to results:", err)
+	}
+}
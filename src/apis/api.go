@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"net/http"
 	"src/util"
-	"github.com/redis/go-redis/v9"
+	"src/database"
+	"errors"
 	"strconv"
 	"src/util/scoring"
+	"src/util/boards"
 	"math"
+	"sort"
 	"src/types"
+	"strings"
 )
 
 const (
@@ -19,37 +23,42 @@ const (
 )
 
 const (
-	VulNone = iota
-	VulNS
-	VulEW
-	VulAll
+	MovementMitchell = iota
+	MovementHowell
+	MovementSkipMitchell
+	MovementRelayMitchell
+)
+
+const (
+	ScoringMatchpoints = iota
+	ScoringCrossIMPs
+	ScoringButler
+)
+
+const (
+	VulNone = boards.VulNone
+	VulNS = boards.VulNS
+	VulEW = boards.VulEW
+	VulAll = boards.VulAll
 )
 
 type Handler struct {
-	Redis *redis.Client
+	Store database.Store
 	WebSocketHub *WebSocketHub
 	ExpectedClients map[string]int
 }
 
-type Tournament struct {
-	Id string
-	BoardsPerRound int
-	TotalRounds int
-	Type int
-	Teams int  //# of Pairs if pair game
-}
-
-type Pair struct {
-	Id string  //1NS or 4EW
-	Name1 string
-	Name2 string 
-	TournamentId string
-}
-
-type BoardState struct {
-	CurrentBoard int
-	CurrentOpp string
-	CurrentRound int
+//shared with the storage layer
+type Tournament = types.Tournament
+type Pair = types.Pair
+type BoardState = types.BoardState
+type PairResultByBoard = types.PairResultByBoard
+
+//board state plus the dealer and vulnerability of the current board
+type BoardStateResponse struct {
+	*BoardState
+	Dealer string
+	Vulnerability string
 }
 
 type PairStateResponse struct {
@@ -65,15 +74,6 @@ type SortedResult struct {
 	Score types.MatchpointScore
 }
 
-type PairResultByBoard struct{
-	BoardNumber int
-	Contract string
-	Result string
-	Direction string
-	RawScore int
-	Percentage float64
-}
-
 func CalculateLeaderboard(h *Handler, ctx context.Context,allResults []types.BoardResult,tournament Tournament,tournamentId string) (map[string]types.MatchpointScore,error) {
 	boardResults := make(map[int][]types.BoardResult)
 	var err error
@@ -82,19 +82,35 @@ func CalculateLeaderboard(h *Handler, ctx context.Context,allResults []types.Boa
 		boardResults[res.BoardNumber] = append(boardResults[res.BoardNumber],res)
 	}
 
+	expectedResults,err := GetExpectedResultsByBoard(tournament)
+	if err != nil {
+		fmt.Println("Unable to get expected results per board, not factoring:",err)
+		expectedResults = make(map[int]int)
+	}
+
 	totalScores := make(map[string]types.MatchpointScore)
+	//top available to each pair over the boards it actually played, so a pair that sat out is not penalised
+	maxMPsByPair := make(map[string]float64)
 	pairResultByBoard := make(map[string]map[int]PairResultByBoard)
-	for boardNumber,results := range boardResults{
+	//par goes beside each result once the board's hands have been solved
+	analyses := make(map[int]*types.Analysis)
+	if deals,err := h.Store.GetDeals(ctx,tournamentId); err == nil {
+		for _,deal := range deals{
+			analyses[deal.BoardNumber] = deal.Analysis
+		}
+	}
+	for boardNumber,allBoardResults := range boardResults{
+		var mpScores map[string]types.MatchpointScore
 		var maxMPs float64
-		mpScores := scoring.CalculateMatchpoints(results)
-		if len(results) == 1{
-			maxMPs = 1
-		} else{
-			maxMPs = float64(len(results)-1)
+		mpScores,maxMPs,err = scoreBoard(allBoardResults,expectedResults[boardNumber],tournament)
+		if err != nil {
+			return nil,fmt.Errorf("failed to score adjusted results on board %d: %w",boardNumber,err)
 		}
 		for pairId,score := range mpScores{
+			maxMPsByPair[pairId] += maxMPs
 			if mpScore,ok := totalScores[pairId]; ok {
 				mpScore.MPScore += score.MPScore
+				mpScore.IMPs += score.IMPs
 				totalScores[pairId] = mpScore
 				fmt.Printf("New totalScores for pair %s is %f by adding mpscore %f\n",pairId,totalScores[pairId].MPScore,score.MPScore)
 			} else{
@@ -104,53 +120,50 @@ func CalculateLeaderboard(h *Handler, ctx context.Context,allResults []types.Boa
 			if _,ok := pairResultByBoard[pairId]; !ok {
 				pairResultByBoard[pairId] = make(map[int]PairResultByBoard)
 			}
-			var percentage float64
-			if len(results) == 1{
-				percentage = 50.00
-			} else{
-				percentage = math.Round((score.MPScore / float64(maxMPs)) * 100 * 100)/100
-			}
 			newPairResultByBoard := PairResultByBoard{
 				BoardNumber: boardNumber,
 				Contract: score.Contract,
 				Result: score.Result,
 				Direction: score.ContractDirection,
 				RawScore: score.RawScore,
-				Percentage: percentage,
+				Percentage: score.Percentage,
+				IMPs: score.IMPs,
+				Datum: score.Datum,
+			}
+			if analysis := analyses[boardNumber]; analysis != nil {
+				newPairResultByBoard.ParScore = analysis.ParScore
+				if score.Direction == "EW" {
+					newPairResultByBoard.ParScore = -analysis.ParScore
+				}
+				newPairResultByBoard.ParContract = analysis.ParContract
 			}
 			pairResultByBoard[pairId][boardNumber] = newPairResultByBoard
 			fmt.Printf("New Result for pair %s on board %d: %+v\n",pairId,boardNumber,newPairResultByBoard)
 		}
 	}
 
-	//set pair individual board statistics to redis
+	//set pair individual board statistics to the store
 	for pairId,results := range pairResultByBoard{
-		key := fmt.Sprintf("tournament:%s:pair:%s:boardResults",tournamentId,pairId)
 		for boardNumber,result := range results{
 			fmt.Printf("Results for board number %d: %+v\n",boardNumber,result)
 
-			data, err := json.Marshal(result)
-			if err != nil {
-				fmt.Println("Error marshaling")
-				continue
-			}
-			err = h.Redis.HSet(ctx,key,fmt.Sprintf("board:%d", boardNumber),string(data)).Err()
+			err := h.Store.SetPairBoardResult(ctx,tournamentId,pairId,result)
 			if err != nil {
-				fmt.Println(err,key)
+				fmt.Println(err,pairId)
 				continue
 			}
 		}
 	}
 
-	numPairs := tournament.Teams/2
-	numBoards := tournament.BoardsPerRound * tournament.TotalRounds
-	maxMPsPerPair := float64(numBoards) * float64(numPairs-1)
-
 	for pairId,score := range totalScores{
-		if numPairs == 1{
+		if tournament.ScoringMethod != ScoringMatchpoints {
+			fmt.Printf("Pair Id %s has %f IMPs\n",pairId,score.IMPs)
+			continue
+		}
+		if tournament.Teams < 2 || maxMPsByPair[pairId] == 0{
 			score.Percentage = 0
 		} else{
-			score.Percentage = math.Round((score.MPScore / maxMPsPerPair) * 100 * 100)/100
+			score.Percentage = math.Round((score.MPScore / maxMPsByPair[pairId]) * 100 * 100)/100
 		}
 		fmt.Printf("Pair Id %s has %f MPs resulting in %f\n",pairId,score.MPScore,score.Percentage)
 		totalScores[pairId] = score
@@ -159,81 +172,111 @@ func CalculateLeaderboard(h *Handler, ctx context.Context,allResults []types.Boa
 	return totalScores,err
 }
 
-func GetTournamentById(h *Handler, ctx context.Context, tournamentId string) (*Tournament, error) {
-	key := fmt.Sprintf("tournament:%s",tournamentId)
-	tournament,err := h.Redis.HGetAll(ctx,key).Result()	
-
+//scores every result on a board against the field, with each pair's percentage of the board
+//maxMPs is the top, factored to the number of times the board is scheduled to be played
+func scoreBoard(allBoardResults []types.BoardResult,expected int,tournament Tournament) (map[string]types.MatchpointScore,float64,error) {
+	var maxMPs float64
+	mpScores := make(map[string]types.MatchpointScore)
+	var artificialScores map[string]types.MatchpointScore
+	var err error
+	//artificial scores are not compared with the field, everything else is
+	results,artificial := scoring.SplitArtificial(allBoardResults)
+	//every board is factored to the top of being played as often as scheduled
+	if expected < len(allBoardResults) {
+		expected = len(allBoardResults)
+	}
+	if expected > 1 {
+		maxMPs = float64(expected-1)
+	} else if len(results) <= 1{
+		maxMPs = 1
+	} else{
+		maxMPs = float64(len(results)-1)
+	}
+	//a fouled board is scored separately within each group that played the same deal
+	for _,group := range scoring.GroupByFoul(results){
+		var groupScores map[string]types.MatchpointScore
+		switch tournament.ScoringMethod {
+			case ScoringCrossIMPs:
+				groupScores = scoring.CalculateCrossIMPs(group)
+			case ScoringButler:
+				groupScores = scoring.CalculateButler(group,scoring.DefaultButlerTrim(len(group)))
+			default:
+				groupScores = scoring.CalculateMatchpoints(group)
+				if expected > 1 && len(group) != expected {
+					groupScores = scoring.ApplyNeuberg(groupScores,len(group),expected)
+				}
+		}
+		for pairId,score := range groupScores{
+			mpScores[pairId] = score
+		}
+	}
+	if tournament.ScoringMethod == ScoringMatchpoints {
+		artificialScores,err = scoring.CalculateArtificialMatchpoints(artificial,maxMPs,GetArtificialPercentages(tournament))
+	} else {
+		artificialScores,err = scoring.CalculateArtificialIMPs(artificial)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch tournament: %w", err)
-	}
-	if len(tournament) == 0 {
-        return nil, fmt.Errorf("tournament %s not found", tournamentId)
-    }
-
-    var t Tournament
-
-    if val, ok := tournament["BoardsPerRound"]; ok {
-        fmt.Sscanf(val, "%d", &t.BoardsPerRound)
-    }
-	if val, ok := tournament["TotalRounds"]; ok {
-        fmt.Sscanf(val, "%d", &t.TotalRounds)
-    }
-    if val, ok := tournament["Type"]; ok {
-        fmt.Sscanf(val, "%d", &t.Type)
-    }
-    if val, ok := tournament["Teams"]; ok {
-        fmt.Sscanf(val, "%d", &t.Teams)
-    }
-
-    return &t, nil
+		return nil,0,err
+	}
+	for pairId,score := range artificialScores{
+		mpScores[pairId] = score
+	}
+
+	for pairId,score := range mpScores{
+		if tournament.ScoringMethod != ScoringMatchpoints {
+			score.Percentage = 0
+		} else if len(results) == 1 && len(artificial) == 0{
+			score.Percentage = 50.00
+		} else{
+			score.Percentage = math.Round((score.MPScore / float64(maxMPs)) * 100 * 100)/100
+		}
+		mpScores[pairId] = score
+	}
+	return mpScores,maxMPs,nil
 }
 
-func GetBoardStateByPairId(h *Handler, ctx context.Context, tournamentId string, pairId string) (*BoardState,error){
-	key := fmt.Sprintf("tournament:%s:pair:%s:state",tournamentId,pairId)
-	data,err := h.Redis.HGetAll(ctx,key).Result()
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch board number: %w",err)
-	}
-	if len(data) == 0 {
-		return nil, fmt.Errorf("board for pair %s not found", pairId)
-	}
-
-	var state BoardState
-    if val, ok := data["CurrentBoard"]; ok {
-        fmt.Sscanf(val, "%d", &state.CurrentBoard)
-    }
-    if val, ok := data["CurrentOpp"]; ok {
-        fmt.Sscanf(val, "%s", &state.CurrentOpp)
-    }
-	if val, ok := data["CurrentRound"]; ok {
-        fmt.Sscanf(val, "%d", &state.CurrentRound)
-    }
-	fmt.Printf("Got board state %+v",state)
-	return &state,nil
+// Club settings for A+, A= and A-, falling back to 60/50/40.
+func GetArtificialPercentages(tournament Tournament) scoring.ArtificialPercentages {
+	percentages := scoring.DefaultArtificialPercentages
+	if tournament.AveragePlus > 0 {
+		percentages.Plus = tournament.AveragePlus
+	}
+	if tournament.Average > 0 {
+		percentages.Even = tournament.Average
+	}
+	if tournament.AverageMinus > 0 {
+		percentages.Minus = tournament.AverageMinus
+	}
+	return percentages
 }
 
-func GetNamesByPairId(h *Handler, ctx context.Context, tournamentId string, pairId string) (string,string,error) {
-	key := fmt.Sprintf("tournament:%s:pair:%s",tournamentId,pairId)
-	data,err := h.Redis.HGetAll(ctx,key).Result()
+func GetTournamentById(h *Handler, ctx context.Context, tournamentId string) (*Tournament, error) {
+	return h.Store.GetTournament(ctx,tournamentId)
+}
+
+func GetBoardStateByPairId(h *Handler, ctx context.Context, tournamentId string, pairId string) (*BoardState,error){
+	state,err := h.Store.GetBoardState(ctx,tournamentId,pairId)
 	if err != nil {
-		return "","",fmt.Errorf("error getting pair info: %w",err)
+		return nil,err
 	}
-	return data["Name1"],data["Name2"],nil
+	fmt.Printf("Got board state %+v",*state)
+	return state,nil
 }
 
-func GetVulByBoardNumber(boardNumber int) int{
-	switch boardNumber % 4 {
-		case 0:
-			return VulAll
-		case 1:
-			return VulNone
-		case 2:
-			return VulNS
-		case 3:
-			return VulEW
-		default:
-			return VulNone
+func SetBoardState(h *Handler, ctx context.Context, tournamentId string, pairId string, state BoardState) error {
+	return h.Store.SetBoardState(ctx,tournamentId,pairId,state)
+}
+
+//names are blank for a pair that has not registered
+func GetNamesByPairId(h *Handler, ctx context.Context, tournamentId string, pairId string) (string,string,error) {
+	pair,err := h.Store.GetPair(ctx,tournamentId,pairId)
+	if errors.Is(err,database.ErrNotFound) {
+		return "","",nil
 	}
+	if err != nil {
+		return "","",err
+	}
+	return pair.Name1,pair.Name2,nil
 }
 
 func GetDirectionFromPairId(pairId string) (string, error) {
@@ -243,155 +286,146 @@ func GetDirectionFromPairId(pairId string) (string, error) {
 	return pairId[len(pairId)-2:], nil
 }
 
-func calculateNextBoard(currentBoard int, currentRound int, boardsPerRound int, totalRounds int, myDirection string,totalPairs int) (int,int,bool,bool,bool) {
-	var nextBoard int
-	roundNumber := currentRound
-	isOver := false
-	isSkip := false
-
-	if currentBoard % boardsPerRound != 0 {
-		fmt.Println("Round not over yet")
-		nextBoard = currentBoard + 1
-	} else{
-		roundNumber++
-		if roundNumber > totalRounds{
-			isOver = true
-			return 0,0,false,isOver,isSkip
-		} else{
-			fmt.Println("Round over, rotate pairs")
-			if myDirection == "NS" {
-				if currentBoard + 1 > (boardsPerRound * totalRounds){
-					nextBoard = (currentBoard + 1) % (boardsPerRound * totalRounds)
-				} else{
-					nextBoard = currentBoard + 1
-				}
-				
-			} else if myDirection == "EW" {
-				if totalPairs % 2 == 0 && currentRound == (totalRounds+1)/2 {
-					nextBoard = (currentBoard + (2 * boardsPerRound) + 1) % (boardsPerRound * totalRounds)
-					isSkip = true
-					fmt.Println("Skip round",currentRound)
-				} else{
-					nextBoard = (currentBoard + boardsPerRound + 1) % (boardsPerRound * totalRounds)
-				}
-			}
+func sortLeaderboard(sortedResults []SortedResult,tournament Tournament) {
+	sort.SliceStable(sortedResults,func(i, j int) bool {
+		if tournament.ScoringMethod != ScoringMatchpoints {
+			return sortedResults[i].Score.IMPs > sortedResults[j].Score.IMPs
 		}
-	}
-	return nextBoard, roundNumber, roundNumber != currentRound, isOver, isSkip
+		return sortedResults[i].Score.Percentage > sortedResults[j].Score.Percentage
+	})
 }
 
-func calculateNextOpp(currentOpp string, totalPairs int, boardsPerRound int, totalRounds int, myDirection string, isNewRound bool,isSkip bool) string {
-	var nextOpp string
+func GetBoardResults(h *Handler,ctx context.Context,tournamentId string) ([]types.BoardResult,error) {
+	return h.Store.GetBoardResults(ctx,tournamentId)
+}
 
-	if !isNewRound{
-		fmt.Println("Round not over yet",currentOpp)
-		return currentOpp
-	}
+func SetBoardResult(h *Handler,ctx context.Context,res types.BoardResult) error {
+	return h.Store.SetBoardResult(ctx,res)
+}
 
-	currentOppNum,err := strconv.Atoi(currentOpp[:len(currentOpp)-2])
+func broadcastResults(h *Handler,ctx context.Context,tournamentId string,tournament Tournament) error {
+	standings,err := calculateStandings(h,ctx,tournamentId,tournament)
 	if err != nil {
-		fmt.Println("Atoi error on opp num parsing")
+		return err
 	}
-	currentOppDir := currentOpp[len(currentOpp)-2:]
-	totalPairsInDirection := totalPairs/2
 
-	if myDirection == "NS" {
-		var nextOppNum int
-		if isSkip {
-			nextOppNum = (currentOppNum-2)
-		} else {
-			nextOppNum = (currentOppNum-1)
-		}
-		
-		if nextOppNum == 0 {
-			nextOppNum = totalPairsInDirection
-		}
-		nextOpp = fmt.Sprintf("%d%s",nextOppNum,currentOppDir)
-	} else if myDirection == "EW" {
-		var nextOppNum int
-		if isSkip {
-			nextOppNum = (currentOppNum+2) %  totalPairsInDirection
-		} else{
-			nextOppNum = (currentOppNum+1) %  totalPairsInDirection
+	if tournament.Type == TeamGame {
+		fmt.Printf("Team Results: %+v\n",standings.Teams)
+		if err := saveTeamStandings(h,ctx,tournamentId,standings.Teams); err != nil {
+			fmt.Println("Unable to save team standings:",err)
 		}
-		nextOpp = fmt.Sprintf("%d%s",nextOppNum,currentOppDir)
+		h.WebSocketHub.Broadcast(tournamentId,map[string]interface{}{
+			"Type": "Results",
+			"Teams": standings.Teams,
+		})
+		return nil
+	}
+
+	message := map[string]interface{}{
+		"Type": "Results",
+	}
+	for section,sortedResults := range standings.Sections{
+		fmt.Printf("%s Results: %+v\n",section,sortedResults)
+		message[section] = sortedResults
+	}
+	if err := saveLeaderboard(h,ctx,tournamentId,standings.Sections); err != nil {
+		fmt.Println("Unable to save leaderboard:",err)
+	}
+	h.WebSocketHub.Broadcast(tournamentId,message)
+
+	return nil
+}
+
+//validates a played result and fills in its vulnerability and NS point of view score,
+//dropping anything only a director may set
+func scoreResult(res *types.BoardResult) error {
+	info,err := boards.Get(res.BoardNumber)
+	if err != nil {
+		return err
+	}
+	vul := info.Vulnerability
+	score,err := scoring.CalculateScore(res.Contract,res.Direction,res.Result,vul)
+	if err != nil {
+		return fmt.Errorf("Invalid result: %w",err)
 	}
-	return nextOpp
+	//results are stored from NS's point of view
+	if res.Direction == "EW" {
+		score = -score
+	}
+	//the seat is optional, but has to be one of the declaring pair's
+	res.DeclarerSeat = strings.ToUpper(strings.TrimSpace(res.DeclarerSeat))
+	if strings.EqualFold(strings.TrimSpace(res.Contract),scoring.PassedOut) {
+		res.DeclarerSeat = ""
+	}
+	if res.DeclarerSeat != "" && (len(res.DeclarerSeat) != 1 || !strings.Contains(res.Direction,res.DeclarerSeat)) {
+		return fmt.Errorf("Invalid result: declarer %q is not sitting %s",res.DeclarerSeat,res.Direction)
+	}
+	res.Vul = strconv.Itoa(vul)
+	res.Score = score
+	res.Adjustment = ""
+	res.NSArtificial = ""
+	res.EWArtificial = ""
+	res.EWScore = 0
+	res.FoulGroup = 0
+	return nil
 }
 
-func broadcastResults(h *Handler,ctx context.Context,tournamentId string,tournament Tournament) error {
-	var results []types.BoardResult
-	var err error
-	pattern := fmt.Sprintf("tournament:%s:board:*",tournamentId)
-	iter := h.Redis.Scan(ctx,0,pattern,0).Iterator()
+//stores a confirmed result and moves both pairs on
+func recordResult(h *Handler,ctx context.Context,res types.BoardResult) (map[string]PairStateResponse,error) {
+	tournament,err := GetTournamentById(h,ctx,res.TournamentId)
+	if err != nil {
+		return nil,fmt.Errorf("unable to get tournament %w",err)
+	}
+	roundBefore,_ := completedRound(h,ctx,res.TournamentId,*tournament)
 
-	for iter.Next(ctx){
-		key := iter.Val()
+	err = SetBoardResult(h,ctx,res)
+	if err != nil {
+		return nil,fmt.Errorf("failed to set result %w",err)
+	}
 
-		data,err := h.Redis.HGetAll(ctx,key).Result()
-		if err != nil {
-			return fmt.Errorf("failed to fetch board result: %w",err)
-		}
+	newBoardStateNS,_,isOverNS := NextState(h,ctx,res.TournamentId,res.NSPairId)
+	newBoardStateEW,_,isOverEW := NextState(h,ctx,res.TournamentId,res.EWPairId)
+	sendTraveller(h,ctx,res.TournamentId,res.BoardNumber)
 
-		br := types.BoardResult{}
-		br.BoardNumber, _ = strconv.Atoi(data["BoardNumber"])
-		br.Contract = data["Contract"]
-		br.Direction = data["Direction"]
-		br.Result = data["Result"]
-		br.NSPairId = data["NSPairId"]
-		br.EWPairId = data["EWPairId"]
-		br.TournamentId = data["TournamentId"]
-		br.Vul = data["Vul"]
-		br.Score = 0 
-		if s, ok := data["Score"]; ok {
-			if val, err := strconv.Atoi(s); err == nil {
-				br.Score = val
-			} else {
-				return fmt.Errorf("invalid Score value for key %s: %w", key, err)
-			}
-		}
-		results = append(results,br)
-	}
-	if err := iter.Err(); err != nil {
-		return fmt.Errorf("iteration error: %w", err)
-	}
-
-	leaderboard,err := CalculateLeaderboard(h,ctx,results,tournament,tournamentId)
-
-	var nsLeaderboard []SortedResult
-	var ewLeaderboard []SortedResult
-
-	for pairId,score := range leaderboard{
-		name1,name2,_ := GetNamesByPairId(h,ctx,tournamentId,pairId)
-		if pairId[1:] == "NS" {
-			nsLeaderboard = append(nsLeaderboard,SortedResult{
-				pairId,
-				name1,
-				name2,
-				score,
-			}) 
-		} else if pairId[1:] == "EW" {
-			ewLeaderboard = append(ewLeaderboard,SortedResult{
-				pairId,
-				name1,
-				name2,
-				score,
-			})
-		}
-		fmt.Printf("Pair %s finished with a score of %f!\n",pairId,score.Percentage)
+	//the last result of the tournament sends out the final results instead
+	roundAfter,_ := completedRound(h,ctx,res.TournamentId,*tournament)
+	if roundAfter < tournament.TotalRounds && (roundAfter > roundBefore || tournament.LiveStandings) {
+		broadcastStandings(h,ctx,res.TournamentId,*tournament)
 	}
 
-	fmt.Printf("NS Results: %+v\n",nsLeaderboard)
-	fmt.Printf("EW Results: %+v\n",ewLeaderboard)
+	if !isOverEW && !isOverNS {
+		fmt.Printf("%+v\n",newBoardStateNS)
+		fmt.Printf("%+v\n",newBoardStateEW)
+	}
 
-	h.WebSocketHub.Broadcast(tournamentId,map[string]interface{}{
-		"Type": "Results",
-		"NS": nsLeaderboard,
-		"EW": ewLeaderboard,
-	})
+	return map[string]PairStateResponse{
+		"NS":{
+			PairId:     res.NSPairId,
+			BoardState: newBoardStateNS,
+			IsOver:     isOverNS,
+		},
+		"EW":{
+			PairId:     res.EWPairId,
+			BoardState: newBoardStateEW,
+			IsOver:     isOverEW,
+		},
+	},nil
+}
 
-	
-	return err
+func finishPair(h *Handler,ctx context.Context,tournamentId string,pairId string,tournament Tournament) error {
+	fmt.Println("Tournament has ended for pair",pairId)
+	finishedCount,added,err := h.Store.FinishPair(ctx,tournamentId,pairId)
+	if err != nil {
+		return fmt.Errorf("unable to mark pair finished %w",err)
+	}
+	if !added {
+		fmt.Println("Pair",pairId,"had already finished")
+		return nil
+	}
+	if finishedCount == totalPairs(tournament) {
+		broadcastResults(h,ctx,tournamentId,tournament)
+	}
+	return nil
 }
 
 func NextState(h *Handler,ctx context.Context,tournamentId string, pairId string) (*BoardState,error,bool) {
@@ -405,47 +439,30 @@ func NextState(h *Handler,ctx context.Context,tournamentId string, pairId string
 	if err != nil {
 		return nil,fmt.Errorf("unable to get tournament %w",err),isOver
 	}
-	boardsPerRound := tournament.BoardsPerRound
-	totalRounds := tournament.TotalRounds
-	totalPairs := tournament.Teams
 
-	myDirection,err := GetDirectionFromPairId(pairId)
+	schedule,err := GetSchedule(*tournament)
 	if err != nil {
-		return nil,fmt.Errorf("unable to get my direction %w",err),isOver
+		return nil,fmt.Errorf("unable to get movement %w",err),isOver
 	}
 
-	nextBoard,currentRound,isNewRound,isOver,isSkip := calculateNextBoard(boardState.CurrentBoard, boardState.CurrentRound, boardsPerRound, totalRounds, myDirection,totalPairs)
+	newBoardState,isOver,err := calculateNextState(*boardState,pairId,schedule)
+	if err != nil {
+		return nil,fmt.Errorf("unable to seat pair %w",err),isOver
+	}
 	if isOver{
-		fmt.Println("Tournament has ended for pair",pairId)
-		finishedKey := fmt.Sprintf("tournament:%s:pairs_finished_counter",tournamentId)
-		finishedCount,err := h.Redis.Incr(ctx,finishedKey).Result()
-		if err != nil {
-			return nil,fmt.Errorf("unable to increment %w",err),isOver
-		}
-		if finishedCount == int64(tournament.Teams) {
-			broadcastResults(h,ctx,tournamentId,*tournament)
-		}
-		return nil,nil,isOver
-	}
-	fmt.Println("Next board is",nextBoard,"for pair",pairId)
-	nextOpp := calculateNextOpp(boardState.CurrentOpp, totalPairs, boardsPerRound, totalRounds, myDirection, isNewRound,isSkip)
-	fmt.Println("Next opp is",nextOpp,"for pair",pairId)
-	
-	fmt.Println("Next round is",currentRound)
-
-	boardStateKey := fmt.Sprintf("tournament:%s:pair:%s:state",tournamentId,pairId)
-	err = h.Redis.HSet(ctx,boardStateKey,map[string]interface{}{
-		"CurrentBoard":nextBoard,
-		"CurrentRound":currentRound,
-		"CurrentOpp":nextOpp,
-	}).Err()
+		return nil,finishPair(h,ctx,tournamentId,pairId,*tournament),isOver
+	}
+	fmt.Printf("Next state for pair %s is %+v\n",pairId,newBoardState)
+
+	err = SetBoardState(h,ctx,tournamentId,pairId,*newBoardState)
 	if err != nil {
 		return nil,fmt.Errorf("unable to update state %w",err),isOver
 	}
-	newBoardState := &BoardState{
-		CurrentBoard:nextBoard,
-		CurrentOpp:nextOpp,
-		CurrentRound:currentRound,
+	if newBoardState.IsSitOut {
+		return settleSitOut(h,ctx,tournamentId,pairId,newBoardState,*tournament,schedule)
+	}
+	if newBoardState.CurrentRound != boardState.CurrentRound {
+		releaseSitOut(h,ctx,tournamentId,newBoardState)
 	}
 
 	return newBoardState,nil,isOver
@@ -455,8 +472,8 @@ func withCORS(handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Allow requests from frontend origin
 		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+SessionHeader+", "+DirectorHeader)
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -467,16 +484,33 @@ func withCORS(handler http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func Routes(mux *http.ServeMux, redisCli *redis.Client){
+func Routes(mux *http.ServeMux, store database.Store){
 	h := &Handler{
-		Redis: redisCli,
+		Store: store,
 		WebSocketHub:NewWebSocketHub(),
 	}
 
 	mux.HandleFunc("/tournament", withCORS(h.TournamentHandler))
 	mux.HandleFunc("/pair", withCORS(h.PairHandler))
+	mux.HandleFunc("/team", withCORS(h.TeamHandler))
 	mux.HandleFunc("/board", withCORS(h.BoardHandler))
+	mux.HandleFunc("/board/confirm", withCORS(h.ConfirmHandler))
 	mux.HandleFunc("/pairresults",withCORS(h.PairResultsHandler))
+	mux.HandleFunc("/director/adjustment",withCORS(h.AdjustmentHandler))
+	mux.HandleFunc("/director/foul",withCORS(h.FoulHandler))
+	mux.HandleFunc("/director/disputes",withCORS(h.DisputeHandler))
+	mux.HandleFunc("/director/result",withCORS(h.CorrectionHandler))
+	mux.HandleFunc("/director/audit",withCORS(h.AuditHandler))
+	mux.HandleFunc("/director/rollback",withCORS(h.RollbackHandler))
+	mux.HandleFunc("/events",withCORS(h.EventsHandler))
+	mux.HandleFunc("/history",withCORS(h.HistoryHandler))
+	mux.HandleFunc("/leaderboard",withCORS(h.LeaderboardHandler))
+	mux.HandleFunc("/traveller",withCORS(h.TravellerHandler))
+	mux.HandleFunc("/deals",withCORS(h.DealsHandler))
+	mux.HandleFunc("/deals/generate",withCORS(h.GenerateDealsHandler))
+	mux.HandleFunc("/export/pbn",withCORS(h.PBNExportHandler))
+	mux.HandleFunc("/export/handrecords",withCORS(h.HandRecordsHandler))
+	mux.HandleFunc("/export/usebio",withCORS(h.USEBIOExportHandler))
 
 	mux.HandleFunc("/ws",h.WsHandler)
 }
@@ -492,7 +526,7 @@ func (h *Handler) TournamentHandler(w http.ResponseWriter, r *http.Request){
 			tournament,err := GetTournamentById(h,ctx,tournamentId)
 
 			if err != nil {
-				http.Error(w,"Redis Get Failed",http.StatusInternalServerError)
+				http.Error(w,"Tournament Get Failed",http.StatusInternalServerError)
 			}
 			
 			fmt.Println(tournamentId,tournament)
@@ -511,6 +545,24 @@ func (h *Handler) TournamentHandler(w http.ResponseWriter, r *http.Request){
 				return
 			}
 			fmt.Printf("%+v\n",newTournament)
+			if newTournament.ScoringMethod < ScoringMatchpoints || newTournament.ScoringMethod > ScoringButler {
+				http.Error(w,"Unknown scoring method",http.StatusBadRequest)
+				return
+			}
+			mov,err := GetMovement(newTournament)
+			if err != nil {
+				http.Error(w,err.Error(),http.StatusBadRequest)
+				return
+			}
+			schedule,err := mov.Schedule()
+			if err != nil {
+				http.Error(w,err.Error(),http.StatusBadRequest)
+				return
+			}
+			if len(mov.Pairs()) != totalPairs(newTournament) {
+				http.Error(w,"The movement needs an even number of pairs",http.StatusBadRequest)
+				return
+			}
 			
 			tournamentId, err := util.GenerateShortID(6)
 			if err != nil {
@@ -519,20 +571,14 @@ func (h *Handler) TournamentHandler(w http.ResponseWriter, r *http.Request){
 			newTournament.Id = tournamentId
 			fmt.Println("Tournament Id:",tournamentId)
 
-			tournamentKey := fmt.Sprintf("tournament:%s",tournamentId)
-
-			err = h.Redis.HSet(ctx,tournamentKey, map[string]interface{}{
-				"Id":newTournament.Id,
-				"BoardsPerRound":newTournament.BoardsPerRound,
-				"TotalRounds":newTournament.TotalRounds,
-				"Type":newTournament.Type,
-				"Teams":newTournament.Teams,
-			}).Err()
+			err = h.Store.SetTournament(ctx,newTournament)
 			if err != nil {
 				http.Error(w,"Failed to store tournament",http.StatusInternalServerError)
+				return
 			}
+			logEvent(h,ctx,newTournament.Id,EventTournamentCreated,newTournament)
 
-			h.WebSocketHub.expectedClientCounts[newTournament.Id] = newTournament.Teams
+			h.WebSocketHub.expectedClientCounts[newTournament.Id] = totalPairs(newTournament)
 
 
 			h.WebSocketHub.OnClientCountChangeMap[newTournament.Id] = func(count int){
@@ -545,8 +591,12 @@ func (h *Handler) TournamentHandler(w http.ResponseWriter, r *http.Request){
 				}
 			}
 
+			//more than 1 when tables sharing a set outnumber its boards, e.g. a 3 table howell of 2 board rounds
 			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(newTournament)
+			json.NewEncoder(w).Encode(struct{
+				Tournament
+				BoardCopies int
+			}{newTournament,schedule.BoardCopies()})
 
 		default:
 			http.Error(w,"Method Not Allowed",http.StatusMethodNotAllowed)
@@ -562,24 +612,19 @@ func (h *Handler) PairHandler(w http.ResponseWriter, r *http.Request){
 			tournamentId := r.URL.Query().Get("tournamentId")
 			pairId := r.URL.Query().Get("pairId")
 
-			key := fmt.Sprintf("tournament:%s:pair:%s",tournamentId,pairId)
-			data,err := h.Redis.HGetAll(ctx,key).Result()
+			pair,err := h.Store.GetPair(ctx,tournamentId,pairId)
+			if errors.Is(err,database.ErrNotFound) {
+				http.Error(w,"Pair not found",http.StatusNotFound)
+				return
+			}
 			if err != nil {
 				http.Error(w,"Unable to get Pair info",http.StatusInternalServerError)
+				return
 			}
-			if data != nil {
-				var pair Pair
-				if val, ok := data["Name1"]; ok {
-					fmt.Sscanf(val, "%s", &pair.Name1)
-				}
-				if val, ok := data["Name2"]; ok {
-					fmt.Sscanf(val, "%s", &pair.Name2)
-				}
 
-				fmt.Printf("%+v",pair)
-				w.Header().Set("Content-Type","application/json")
-				json.NewEncoder(w).Encode(pair)
-			}
+			fmt.Printf("%+v",*pair)
+			w.Header().Set("Content-Type","application/json")
+			json.NewEncoder(w).Encode(Pair{Name1: pair.Name1,Name2: pair.Name2})
 
 
 		case "POST":
@@ -592,66 +637,80 @@ func (h *Handler) PairHandler(w http.ResponseWriter, r *http.Request){
 
 			fmt.Printf("%+v\n",newPair)
 
-			counterKey := fmt.Sprintf("tournament:%s:pair_counter",newPair.TournamentId)
-			pairCount,err := h.Redis.Incr(ctx,counterKey).Result()
+			pairCount,err := h.Store.NextPairNumber(ctx,newPair.TournamentId)
 			if err != nil {
 				http.Error(w,"Couldn't get pair count",http.StatusInternalServerError)
+				return
 			}
 			tournament,err := GetTournamentById(h,ctx,newPair.TournamentId)
 			if err != nil {
-				h.Redis.Decr(ctx, counterKey)
+				h.Store.ReleasePairNumber(ctx,newPair.TournamentId)
 				http.Error(w,"Couldn't get tournament",http.StatusInternalServerError)
 				return
 			}
 
-			if int(pairCount) > tournament.Teams {
-				h.Redis.Decr(ctx, counterKey)
+			if tournament.Type == TeamGame {
+				h.Store.ReleasePairNumber(ctx,newPair.TournamentId)
+				http.Error(w,"Register pairs through /team in a team game",http.StatusBadRequest)
+				return
+			}
+
+			if pairCount > tournament.Teams {
+				h.Store.ReleasePairNumber(ctx,newPair.TournamentId)
 				http.Error(w,"Tournament is full",http.StatusForbidden)
 				return
 			}
 
 			fmt.Println("Got the",pairCount,"pair!")
 
-			tableNum := (int)(pairCount + 1)/2
-			dir := "NS"
-			opp := fmt.Sprintf("%dEW",tableNum)
-			if pairCount % 2 == 0{
-				dir = "EW"
-				opp = fmt.Sprintf("%dNS",tableNum)
+			mov,err := GetMovement(*tournament)
+			if err != nil {
+				h.Store.ReleasePairNumber(ctx,newPair.TournamentId)
+				http.Error(w,"Could not build movement",http.StatusInternalServerError)
+				return
+			}
+			schedule,err := mov.Schedule()
+			if err != nil {
+				h.Store.ReleasePairNumber(ctx,newPair.TournamentId)
+				http.Error(w,"Could not build movement",http.StatusInternalServerError)
+				return
+			}
+			newPair.Id = mov.Pairs()[pairCount-1]
+			if _,err := schedule.FindSeat(1,newPair.Id); err != nil {
+				h.Store.ReleasePairNumber(ctx,newPair.TournamentId)
+				http.Error(w,"Could not seat pair",http.StatusInternalServerError)
+				return
 			}
-
-			newPair.Id = fmt.Sprintf("%d%s",tableNum,dir)
 
 			fmt.Println("You are the following pair:",newPair.Id)
 
-			pairKey := fmt.Sprintf("tournament:%s:pair:%s",newPair.TournamentId,newPair.Id)
-
-			err = h.Redis.HSet(ctx, pairKey, map[string]interface{}{
-				"Id": newPair.Id,
-				"Name1": newPair.Name1,
-				"Name2": newPair.Name2,
-				"TournamentId":newPair.TournamentId,
-			}).Err()
-
+			secret,secretHash,err := newPairSecret()
+			if err != nil {
+				h.Store.ReleasePairNumber(ctx,newPair.TournamentId)
+				http.Error(w,"Could not make pair secret",http.StatusInternalServerError)
+				return
+			}
+			newPair.SecretHash = secretHash
+			err = h.Store.SetPair(ctx,newPair)
 			if err != nil {
-				h.Redis.Decr(ctx, counterKey)
+				h.Store.ReleasePairNumber(ctx,newPair.TournamentId)
 				http.Error(w, "Failed to store pair", http.StatusInternalServerError)
             	return
 			}
 
-			currentBoard := (tableNum - 1) * tournament.BoardsPerRound + 1
-			boardStateKey := fmt.Sprintf("tournament:%s:pair:%s:state",newPair.TournamentId,newPair.Id)
-			err = h.Redis.HSet(ctx,boardStateKey,map[string]interface{}{
-				"CurrentBoard":currentBoard,
-				"CurrentOpp":opp,
-				"CurrentRound":1,
-			}).Err()
+			err = seatPair(h,ctx,*tournament,schedule,newPair)
 			if err != nil {
 				http.Error(w, "Could not assign boards to pair", http.StatusInternalServerError)
+				return
 			}
+			logEvent(h,ctx,newPair.TournamentId,EventPairJoined,newPair)
 
+			//the only time the secret is sent, the pair opens its WebSocket connection with it
 			w.Header().Set("Content-Type","application/json")
-			json.NewEncoder(w).Encode(newPair)
+			json.NewEncoder(w).Encode(struct{
+				Pair
+				Secret string
+			}{newPair,secret})
 
 		default:
 			fmt.Println("Unknown request method")
@@ -669,96 +728,109 @@ func (h *Handler) BoardHandler(w http.ResponseWriter, r *http.Request){
 
 			fmt.Println("Tournament:",tournamentId,"Pair:",pairId)
 
+			//the hands of a board are shown once the pair has played it, to the pair holding its connection's token
+			if board := r.URL.Query().Get("board"); board != "" {
+				boardNumber,err := strconv.Atoi(board)
+				if err != nil {
+					http.Error(w,"Invalid board number",http.StatusBadRequest)
+					return
+				}
+				pairId,connected := requestingPair(h,r,tournamentId)
+				if !connected {
+					http.Error(w,"The pair's session token is required",http.StatusUnauthorized)
+					return
+				}
+				played,err := hasPlayedBoard(h,ctx,tournamentId,pairId,boardNumber)
+				if err != nil {
+					http.Error(w,"Unable to check board",http.StatusInternalServerError)
+					return
+				}
+				if !played {
+					http.Error(w,"The hands are shown once the pair has played the board",http.StatusForbidden)
+					return
+				}
+				deal,err := getDeal(h,ctx,tournamentId,boardNumber)
+				if err != nil {
+					http.Error(w,"Unable to get deal",http.StatusInternalServerError)
+					return
+				}
+				if deal == nil {
+					http.Error(w,"No hands were imported for this board",http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type","application/json")
+				json.NewEncoder(w).Encode(deal)
+				return
+			}
+
 			boardState,err := GetBoardStateByPairId(h,ctx,tournamentId,pairId)
 			if err != nil {
 				http.Error(w,"Unable to get current board number",http.StatusInternalServerError)
+				return
 			}
 
 			fmt.Printf("%+v",boardState)
 
+			response := BoardStateResponse{BoardState: boardState}
+			if info,err := boards.Get(boardState.CurrentBoard); err == nil {
+				response.Dealer = info.Dealer
+				response.Vulnerability = info.VulName
+			}
+
 			w.Header().Set("Content-Type","application/json")
-			json.NewEncoder(w).Encode(boardState)
+			json.NewEncoder(w).Encode(response)
 
 		case "POST":
-			var newResult types.BoardResult
-			err := json.NewDecoder(r.Body).Decode(&newResult)
+			var pending types.PendingResult
+			err := json.NewDecoder(r.Body).Decode(&pending)
 			if err != nil {
 				http.Error(w,"Invalid JSON payload",http.StatusBadRequest)
 				return
 			}
 
-			vul := GetVulByBoardNumber(newResult.BoardNumber)
-			score := scoring.CalculateScore(newResult.Contract,newResult.Direction,newResult.Result,vul)
-
-			//Update score for the NS pair
-			ResultKeyNS := fmt.Sprintf("tournament:%s:board:%d:pair:%s",newResult.TournamentId,newResult.BoardNumber,newResult.NSPairId)
-			err = h.Redis.HSet(ctx,ResultKeyNS,map[string]interface{}{
-				"BoardNumber": newResult.BoardNumber,
-				"Vul": vul,
-				"Contract": newResult.Contract,
-				"Direction": newResult.Direction,
-				"Result": newResult.Result,
-				"NSPairId": newResult.NSPairId,
-				"EWPairId": newResult.EWPairId,
-				"TournamentId": newResult.TournamentId,
-				"Score":score,
-			}).Err()
+			//NS enter the result unless the client says otherwise
+			if pending.SubmittedBy == "" {
+				pending.SubmittedBy = pending.NSPairId
+			}
+			if pending.SubmittedBy != pending.NSPairId && pending.SubmittedBy != pending.EWPairId {
+				http.Error(w,"Result must be entered by one of the pairs at the table",http.StatusBadRequest)
+				return
+			}
+			err = scoreResult(&pending.BoardResult)
 			if err != nil {
-				http.Error(w,"Failed to set NS pair result",http.StatusInternalServerError)
+				http.Error(w,err.Error(),http.StatusBadRequest)
 				return
 			}
-
-			//Update score for the EW pair
-			/*ResultKeyEW := fmt.Sprintf("tournament:%s:board:%d:pair:%s",newResult.TournamentId,newResult.BoardNumber,newResult.EWPairId)
-			err = h.Redis.HSet(ctx,ResultKeyEW,map[string]interface{}{
-				"BoardNumber": newResult.BoardNumber,
-				"Vul": vul,
-				"Contract": newResult.Contract,
-				"Direction": newResult.Direction,
-				"Result": newResult.Result,
-				"NSPairId": newResult.NSPairId,
-				"EWPairId": newResult.EWPairId,
-				"TournamentId": newResult.TournamentId,
-				"Score":-score,
-			}).Err()
+			err = checkSubmission(h,ctx,pending.BoardResult)
 			if err != nil {
-				http.Error(w,"Failed to set NS pair result",http.StatusInternalServerError)
+				submissionError(w,err)
 				return
-			}*/
-
-
-			newBoardStateNS,_,isOverNS := NextState(h,ctx,newResult.TournamentId,newResult.NSPairId)
-			newBoardStateEW,_,isOverEW := NextState(h,ctx,newResult.TournamentId,newResult.EWPairId)
-
-			if !isOverEW && !isOverNS {
-				fmt.Printf("%+v\n",newBoardStateNS)
-				fmt.Printf("%+v\n",newBoardStateEW)
 			}
-			
-			if isOverNS{
-				finishKey := "tournament:%s:finished_pairs"
-				h.Redis.SAdd(ctx,finishKey,newResult.NSPairId)
+
+			existing,err := h.Store.GetPendingResult(ctx,pending.TournamentId,pending.BoardNumber,pending.NSPairId)
+			if err == nil && existing.Status == ResultDisputed {
+				http.Error(w,"Result is disputed and waiting for the director",http.StatusConflict)
+				return
 			}
-			if isOverEW{
-				finishKey := "tournament:%s:finished_pairs"
-				h.Redis.SAdd(ctx,finishKey,newResult.EWPairId)
+			pending.Status = ResultPending
+			pending.DisputeReason = ""
+			err = h.Store.SetPendingResult(ctx,pending)
+			if err != nil {
+				http.Error(w,"Failed to store result",http.StatusInternalServerError)
+				return
 			}
+			fmt.Printf("Result on board %d entered by %s: %+v\n",pending.BoardNumber,pending.SubmittedBy,pending.BoardResult)
 
-			response := map[string]PairStateResponse{
-				"NS":{
-					PairId:     newResult.NSPairId,
-					BoardState: newBoardStateNS,
-					IsOver:     isOverNS,
-				},
-				"EW":{
-					PairId:     newResult.EWPairId,
-					BoardState: newBoardStateEW,
-					IsOver:     isOverEW,
-				},
-			}
+			//the other pair at the table is asked to confirm
+			h.WebSocketHub.Broadcast(pending.TournamentId,map[string]interface{}{
+				"Type": "ConfirmResult",
+				"PairId": opponentAtTable(pending.BoardResult,pending.SubmittedBy),
+				"Result": pending,
+			})
 
 			w.Header().Set("Content-Type","application/json")
-			json.NewEncoder(w).Encode(response)
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(pending)
 
 		default:
 			fmt.Println("Unknown request method")
@@ -778,20 +850,10 @@ func (h *Handler) PairResultsHandler(w http.ResponseWriter,r *http.Request){
 				return
 			}
 
-			key := fmt.Sprintf("tournament:%s:pair:%s:boardResults",tournamentId,pairId)
-			response,err := h.Redis.HGetAll(ctx,key).Result()
+			pairResults,err := h.Store.GetPairBoardResults(ctx,tournamentId,pairId)
 			if err != nil {
 				http.Error(w,"Failed to retrieve board results",http.StatusInternalServerError)
-			}
-
-			var pairResults []PairResultByBoard
-			for field,value := range response{
-				var pairResult PairResultByBoard
-				if err := json.Unmarshal([]byte(value),&pairResult); err != nil{
-					fmt.Printf("Failed to unmarshal board %s: %v\n", field, err)
-					continue 
-				}
-				pairResults = append(pairResults,pairResult)
+				return
 			}
 
 			w.Header().Set("Content-Type","application/json")
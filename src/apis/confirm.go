@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"src/database"
+	"src/types"
+)
+
+const (
+	ResultPending  = "Pending"
+	ResultDisputed = "Disputed"
+)
+
+type Confirmation struct {
+	TournamentId string
+	BoardNumber  int
+	NSPairId     string
+	PairId       string //pair answering, the opponents of the pair that entered the result
+	Confirmed    bool
+	Reason       string //why the result is disputed
+}
+
+func opponentAtTable(res types.BoardResult, pairId string) string {
+	if pairId == res.NSPairId {
+		return res.EWPairId
+	}
+	return res.NSPairId
+}
+
+// Codes sent back when a submitted result does not fit the schedule.
+const (
+	CodeResultRecorded    = "RESULT_ALREADY_RECORDED"
+	CodePairNotFound      = "PAIR_NOT_FOUND"
+	CodePairSittingOut    = "PAIR_SITTING_OUT"
+	CodeDirectionMismatch = "DIRECTION_MISMATCH"
+	CodeRoundMismatch     = "ROUND_MISMATCH"
+	CodeBoardMismatch     = "BOARD_MISMATCH"
+	CodeOpponentMismatch  = "OPPONENT_MISMATCH"
+)
+
+type SubmissionError struct {
+	Code    string
+	Message string
+}
+
+func (e *SubmissionError) Error() string {
+	return e.Message
+}
+
+func rejectSubmission(code string, format string, args ...interface{}) *SubmissionError {
+	return &SubmissionError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// A table enters one result, for the board both its pairs are on. Nothing the
+// client sends is trusted: the board, round and opponents have to match what
+// is stored for both pairs, and a result sent again after it was recorded
+// would otherwise move both pairs on a second time.
+func checkSubmission(h *Handler, ctx context.Context, res types.BoardResult) error {
+	_, err := h.Store.GetBoardResult(ctx, res.TournamentId, res.BoardNumber, res.NSPairId)
+	if err == nil {
+		return rejectSubmission(CodeResultRecorded, "A result is already recorded for board %d of pair %s", res.BoardNumber, res.NSPairId)
+	}
+	if !errors.Is(err, database.ErrNotFound) {
+		return err
+	}
+
+	pairIds := []string{res.NSPairId, res.EWPairId}
+	states := make(map[string]*BoardState)
+	for _, pairId := range pairIds {
+		state, err := GetBoardStateByPairId(h, ctx, res.TournamentId, pairId)
+		if errors.Is(err, database.ErrNotFound) {
+			return rejectSubmission(CodePairNotFound, "Pair %s is not registered", pairId)
+		}
+		if err != nil {
+			return err
+		}
+		if state.IsSitOut {
+			return rejectSubmission(CodePairSittingOut, "Pair %s is sitting out", pairId)
+		}
+		states[pairId] = state
+	}
+	ns, ew := states[res.NSPairId], states[res.EWPairId]
+
+	if ns.Direction != "NS" || ew.Direction != "EW" {
+		return rejectSubmission(CodeDirectionMismatch, "Pair %s is sitting %s and pair %s is sitting %s", res.NSPairId, ns.Direction, res.EWPairId, ew.Direction)
+	}
+	if ns.CurrentRound != ew.CurrentRound {
+		return rejectSubmission(CodeRoundMismatch, "Pair %s is in round %d but pair %s is in round %d", res.NSPairId, ns.CurrentRound, res.EWPairId, ew.CurrentRound)
+	}
+	for _, pairId := range pairIds {
+		if states[pairId].CurrentBoard != res.BoardNumber {
+			return rejectSubmission(CodeBoardMismatch, "Pair %s is on board %d, not board %d", pairId, states[pairId].CurrentBoard, res.BoardNumber)
+		}
+	}
+	if ns.CurrentOpp != res.EWPairId || ew.CurrentOpp != res.NSPairId {
+		return rejectSubmission(CodeOpponentMismatch, "Pair %s is playing %s and pair %s is playing %s", res.NSPairId, ns.CurrentOpp, res.EWPairId, ew.CurrentOpp)
+	}
+	return nil
+}
+
+// A rejected submission is sent back as JSON so clients can act on the code.
+func submissionError(w http.ResponseWriter, err error) {
+	var rejected *SubmissionError
+	if !errors.As(err, &rejected) {
+		fmt.Println("Unable to check result:", err)
+		http.Error(w, "Failed to check result", http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusConflict
+	if rejected.Code == CodePairNotFound {
+		status = http.StatusNotFound
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(rejected)
+}
+
+func (h *Handler) ConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Confirm", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "POST":
+			var confirmation Confirmation
+			err := json.NewDecoder(r.Body).Decode(&confirmation)
+			if err != nil {
+				http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+				return
+			}
+
+			pending, err := h.Store.GetPendingResult(ctx, confirmation.TournamentId, confirmation.BoardNumber, confirmation.NSPairId)
+			if errors.Is(err, database.ErrNotFound) {
+				//a retried confirmation finds the result already recorded
+				res := types.BoardResult{TournamentId: confirmation.TournamentId, BoardNumber: confirmation.BoardNumber, NSPairId: confirmation.NSPairId}
+				var rejected *SubmissionError
+				if err := checkSubmission(h, ctx, res); errors.As(err, &rejected) && rejected.Code == CodeResultRecorded {
+					submissionError(w, rejected)
+					return
+				}
+				http.Error(w, "No result waiting for confirmation", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Failed to get result", http.StatusInternalServerError)
+				return
+			}
+			if pending.Status != ResultPending {
+				http.Error(w, "Result is disputed and waiting for the director", http.StatusConflict)
+				return
+			}
+			if confirmation.PairId != opponentAtTable(pending.BoardResult, pending.SubmittedBy) {
+				http.Error(w, "Only the opponents of the pair that entered the result can confirm it", http.StatusForbidden)
+				return
+			}
+
+			if !confirmation.Confirmed {
+				pending.Status = ResultDisputed
+				pending.DisputeReason = confirmation.Reason
+				err = h.Store.SetPendingResult(ctx, *pending)
+				if err != nil {
+					http.Error(w, "Failed to store disputed result", http.StatusInternalServerError)
+					return
+				}
+				fmt.Printf("Pair %s disputed the result on board %d: %s\n", confirmation.PairId, pending.BoardNumber, pending.DisputeReason)
+				h.WebSocketHub.Broadcast(pending.TournamentId, map[string]interface{}{
+					"Type":   "ResultDisputed",
+					"Result": pending,
+				})
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(pending)
+				return
+			}
+
+			err = checkSubmission(h, ctx, pending.BoardResult)
+			if err != nil {
+				submissionError(w, err)
+				return
+			}
+			//claiming the pending result is what lets a confirmation record it, so a
+			//retry racing the first request cannot move both pairs on twice
+			removed, err := h.Store.DeletePendingResult(ctx, pending.TournamentId, pending.BoardNumber, pending.NSPairId)
+			if err != nil {
+				http.Error(w, "Failed to clear confirmed result", http.StatusInternalServerError)
+				return
+			}
+			if !removed {
+				submissionError(w, rejectSubmission(CodeResultRecorded, "A result is already recorded for board %d of pair %s", pending.BoardNumber, pending.NSPairId))
+				return
+			}
+			response, err := recordResult(h, ctx, pending.BoardResult)
+			if err != nil {
+				http.Error(w, "Failed to record result", http.StatusInternalServerError)
+				return
+			}
+			logEvent(h, ctx, pending.TournamentId, EventResultSubmitted, pending.BoardResult)
+			//the pair that entered the result is waiting on this to move on
+			h.WebSocketHub.Broadcast(pending.TournamentId, map[string]interface{}{
+				"Type":   "ResultConfirmed",
+				"Result": pending.BoardResult,
+				"States": response,
+			})
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(response)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
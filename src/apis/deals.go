@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"src/database"
+	"src/types"
+	"src/util/boards"
+	"src/util/dealer"
+	"src/util/pbn"
+	"strings"
+)
+
+// Large enough for a PBN file with commentary on every board.
+const maxPBNSize = 10 << 20
+
+// Results are scored on the board number's dealer and vulnerability, so a
+// deal has to agree with them and be a board the movement plays.
+func checkDeal(deal types.Deal, expectedResults map[int]int) error {
+	if _, ok := expectedResults[deal.BoardNumber]; !ok {
+		return fmt.Errorf("board %d is not played in this tournament", deal.BoardNumber)
+	}
+	info, err := boards.Get(deal.BoardNumber)
+	if err != nil {
+		return err
+	}
+	if deal.Dealer != info.Dealer || deal.Vulnerability != info.VulName {
+		return fmt.Errorf("board %d is dealt by %s with %s vulnerable, not %s with %s", deal.BoardNumber, info.Dealer, info.VulName, deal.Dealer, deal.Vulnerability)
+	}
+	return nil
+}
+
+func hasPlayedBoard(h *Handler, ctx context.Context, tournamentId string, pairId string, boardNumber int) (bool, error) {
+	results, err := GetBoardResults(h, ctx, tournamentId)
+	if err != nil {
+		return false, err
+	}
+	for _, res := range results {
+		if res.BoardNumber == boardNumber && (res.NSPairId == pairId || res.EWPairId == pairId) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// The hands of a board, nil when none were imported.
+func getDeal(h *Handler, ctx context.Context, tournamentId string, boardNumber int) (*types.Deal, error) {
+	deal, err := h.Store.GetDeal(ctx, tournamentId, boardNumber)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	return deal, err
+}
+
+// Stores a tournament's deals, solves them in the background and answers
+// with the boards stored.
+func storeDeals(h *Handler, ctx context.Context, w http.ResponseWriter, tournamentId string, deals []types.Deal) {
+	for _, deal := range deals {
+		err := h.Store.SetDeal(ctx, deal)
+		if err != nil {
+			http.Error(w, "Failed to store deals", http.StatusInternalServerError)
+			return
+		}
+	}
+	fmt.Println("Stored", len(deals), "deals for tournament", tournamentId)
+	go analyseDeals(h, tournamentId, deals)
+
+	//the hands are not sent back, they are only shown once a board is played
+	var boardNumbers []int
+	for _, deal := range deals {
+		boardNumbers = append(boardNumbers, deal.BoardNumber)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"TournamentId": tournamentId,
+		"Boards":       boardNumbers,
+	})
+}
+
+// Takes a PBN file as the request body, or as the file field of a form.
+// GET hands the stored deals back as a PBN file for a dealing machine.
+func (h *Handler) DealsHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Deals", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "GET":
+			//the hands stay with the director until every board has been played
+			if !requireDirector(w, r) {
+				return
+			}
+			tournamentId := r.URL.Query().Get("tournamentId")
+			tournament, err := GetTournamentById(h, ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+			if !isTournamentOver(h, ctx, tournamentId, *tournament) {
+				http.Error(w, "Tournament is not finished", http.StatusConflict)
+				return
+			}
+			deals, err := h.Store.GetDeals(ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Failed to get deals", http.StatusInternalServerError)
+				return
+			}
+			sort.Slice(deals, func(i, j int) bool { return deals[i].BoardNumber < deals[j].BoardNumber })
+
+			session := pbn.Session{Event: tournamentId, Date: sessionDate(h, ctx, tournamentId, "2006.01.02")}
+			for i, deal := range deals {
+				session.Games = append(session.Games, pbn.Game{
+					BoardNumber: deal.BoardNumber,
+					Dealer:      deal.Dealer,
+					Vulnerable:  deal.Vulnerability,
+					Deal:        &deals[i],
+				})
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-deals.pbn\"", tournamentId))
+			err = pbn.Write(w, session)
+			if err != nil {
+				fmt.Println("Unable to write deals:", err)
+			}
+
+		case "POST":
+			tournamentId := r.URL.Query().Get("tournamentId")
+			tournament, err := GetTournamentById(h, ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, maxPBNSize)
+			var file io.Reader = r.Body
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+				upload, _, err := r.FormFile("file")
+				if err != nil {
+					http.Error(w, "Missing PBN file", http.StatusBadRequest)
+					return
+				}
+				defer upload.Close()
+				file = upload
+			}
+
+			deals, err := pbn.Parse(file)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid PBN file: %v", err), http.StatusBadRequest)
+				return
+			}
+			if len(deals) == 0 {
+				http.Error(w, "PBN file has no deals", http.StatusBadRequest)
+				return
+			}
+			expectedResults, err := GetExpectedResultsByBoard(*tournament)
+			if err != nil {
+				http.Error(w, "Could not build movement", http.StatusInternalServerError)
+				return
+			}
+			//nothing is stored unless every deal fits
+			for _, deal := range deals {
+				if err := checkDeal(deal, expectedResults); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			for i := range deals {
+				deals[i].TournamentId = tournamentId
+			}
+			storeDeals(h, ctx, w, tournamentId, deals)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Deals every board of the session from the tournament id, so asking again
+// deals the same hands. The body can limit what each seat is dealt, e.g.
+// {"N": {"MinHCP": 15, "MaxHCP": 17, "Shapes": ["balanced"]}}.
+func (h *Handler) GenerateDealsHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Generate Deals", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "POST":
+			tournamentId := r.URL.Query().Get("tournamentId")
+			tournament, err := GetTournamentById(h, ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+			//no body deals without constraints
+			var constraints dealer.Constraints
+			err = json.NewDecoder(r.Body).Decode(&constraints)
+			if err != nil && !errors.Is(err, io.EOF) {
+				http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+				return
+			}
+
+			//every board the movement plays, which can be more than a set a round
+			expectedResults, err := GetExpectedResultsByBoard(*tournament)
+			if err != nil {
+				http.Error(w, "Could not build movement", http.StatusInternalServerError)
+				return
+			}
+			if len(expectedResults) == 0 {
+				http.Error(w, "Tournament has no boards", http.StatusBadRequest)
+				return
+			}
+			var boardNumbers []int
+			for boardNumber := range expectedResults {
+				boardNumbers = append(boardNumbers, boardNumber)
+			}
+			sort.Ints(boardNumbers)
+			deals, err := dealer.Generate(tournamentId, boardNumbers, constraints)
+			if errors.Is(err, dealer.ErrImpossible) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			storeDeals(h, ctx, w, tournamentId, deals)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,491 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"src/database"
+	"src/types"
+	"src/util/boards"
+	"src/util/scoring"
+	"strconv"
+	"time"
+)
+
+type Adjustment struct {
+	TournamentId string
+	BoardNumber  int
+	NSPairId     string
+	EWPairId     string
+	Type         string //Artificial or Assigned
+	NSArtificial string //A+, A= or A- for an artificial score
+	EWArtificial string
+	NSScore      int  //NS point of view scores for an assigned score, equal unless split
+	EWScore      *int //left out for the same score both ways
+}
+
+func isTournamentOver(h *Handler, ctx context.Context, tournamentId string, tournament Tournament) bool {
+	finishedCount, err := h.Store.FinishedPairCount(ctx, tournamentId)
+	if err != nil {
+		return false
+	}
+	return finishedCount >= totalPairs(tournament)
+}
+
+func isPairFinished(h *Handler, ctx context.Context, tournamentId string, pairId string) bool {
+	finished, err := h.Store.IsPairFinished(ctx, tournamentId, pairId)
+	return err == nil && finished
+}
+
+// Moves both pairs on if they were still sitting at the board, as when a
+// board cannot be played at all.
+func advanceIfCurrent(h *Handler, ctx context.Context, tournamentId string, boardNumber int, nsPairId string, ewPairId string) map[string]PairStateResponse {
+	response := make(map[string]PairStateResponse)
+	for direction, pairId := range map[string]string{"NS": nsPairId, "EW": ewPairId} {
+		boardState, err := GetBoardStateByPairId(h, ctx, tournamentId, pairId)
+		if err != nil || boardState.CurrentBoard != boardNumber || isPairFinished(h, ctx, tournamentId, pairId) {
+			response[direction] = PairStateResponse{PairId: pairId, BoardState: boardState}
+			continue
+		}
+		newBoardState, err, isOver := NextState(h, ctx, tournamentId, pairId)
+		if err != nil {
+			fmt.Println("Unable to move pair", pairId, "on:", err)
+		}
+		response[direction] = PairStateResponse{PairId: pairId, BoardState: newBoardState, IsOver: isOver}
+	}
+	return response
+}
+
+func applyAdjustment(h *Handler, ctx context.Context, result types.BoardResult) (map[string]PairStateResponse, error) {
+	err := SetBoardResult(h, ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Adjusted score on board %d: %+v\n", result.BoardNumber, result)
+	//an adjustment settles anything still waiting on the table
+	_, err = h.Store.DeletePendingResult(ctx, result.TournamentId, result.BoardNumber, result.NSPairId)
+	if err != nil {
+		fmt.Println("Unable to clear pending result:", err)
+	}
+	sendTraveller(h, ctx, result.TournamentId, result.BoardNumber)
+	return advanceIfCurrent(h, ctx, result.TournamentId, result.BoardNumber, result.NSPairId, result.EWPairId), nil
+}
+
+func (h *Handler) AdjustmentHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Adjustment", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "POST":
+			if !requireDirector(w, r) {
+				return
+			}
+			var adjustment Adjustment
+			err := json.NewDecoder(r.Body).Decode(&adjustment)
+			if err != nil {
+				http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+				return
+			}
+
+			tournament, err := GetTournamentById(h, ctx, adjustment.TournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+			if tournament.Type == TeamGame {
+				http.Error(w, "Adjusted scores are not supported in a team game", http.StatusBadRequest)
+				return
+			}
+			if adjustment.BoardNumber < 1 || adjustment.NSPairId == "" || adjustment.EWPairId == "" {
+				http.Error(w, "Missing board number or pair ids", http.StatusBadRequest)
+				return
+			}
+
+			result := types.BoardResult{
+				BoardNumber:  adjustment.BoardNumber,
+				Vul:          strconv.Itoa(boards.Vulnerability(adjustment.BoardNumber)),
+				NSPairId:     adjustment.NSPairId,
+				EWPairId:     adjustment.EWPairId,
+				TournamentId: adjustment.TournamentId,
+				Adjustment:   adjustment.Type,
+			}
+			switch adjustment.Type {
+				case scoring.AdjustmentArtificial:
+					if scoring.ValidateArtificial(adjustment.NSArtificial) != nil || scoring.ValidateArtificial(adjustment.EWArtificial) != nil {
+						http.Error(w, "Artificial scores must be A+, A= or A-", http.StatusBadRequest)
+						return
+					}
+					result.NSArtificial = adjustment.NSArtificial
+					result.EWArtificial = adjustment.EWArtificial
+					result.Result = adjustment.NSArtificial + "/" + adjustment.EWArtificial
+				case scoring.AdjustmentAssigned:
+					result.Score = adjustment.NSScore
+					result.EWScore = adjustment.NSScore
+					if adjustment.EWScore != nil {
+						result.EWScore = *adjustment.EWScore
+					}
+				default:
+					http.Error(w, "Adjustment type must be Artificial or Assigned", http.StatusBadRequest)
+					return
+			}
+
+			response, err := applyAdjustment(h, ctx, result)
+			if err != nil {
+				http.Error(w, "Failed to store adjusted score", http.StatusInternalServerError)
+				return
+			}
+			logEvent(h, ctx, result.TournamentId, EventAdjustmentApplied, result)
+			if isTournamentOver(h, ctx, adjustment.TournamentId, *tournament) {
+				broadcastResults(h, ctx, adjustment.TournamentId, *tournament)
+			} else {
+				broadcastStandings(h, ctx, adjustment.TournamentId, *tournament)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(response)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type FoulGroups struct {
+	TournamentId string
+	BoardNumber  int
+	Groups       map[string]int //NS pair id of each result on the board to the deal it played
+}
+
+// Every result named has to be on the board before any is marked.
+func applyFoulGroups(h *Handler, ctx context.Context, foul FoulGroups) error {
+	results := make(map[string]*types.BoardResult)
+	for nsPairId := range foul.Groups {
+		result, err := h.Store.GetBoardResult(ctx, foul.TournamentId, foul.BoardNumber, nsPairId)
+		if err != nil {
+			return fmt.Errorf("no result for pair %s on board %d: %w", nsPairId, foul.BoardNumber, err)
+		}
+		results[nsPairId] = result
+	}
+	for nsPairId, group := range foul.Groups {
+		results[nsPairId].FoulGroup = group
+		err := SetBoardResult(h, ctx, *results[nsPairId])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Result of %s on board %d is in foul group %d\n", nsPairId, foul.BoardNumber, group)
+	}
+	return nil
+}
+
+func (h *Handler) FoulHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Foul", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "POST":
+			if !requireDirector(w, r) {
+				return
+			}
+			var foul FoulGroups
+			err := json.NewDecoder(r.Body).Decode(&foul)
+			if err != nil {
+				http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+				return
+			}
+
+			tournament, err := GetTournamentById(h, ctx, foul.TournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+
+			err = applyFoulGroups(h, ctx, foul)
+			if errors.Is(err, database.ErrNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Failed to mark fouled result", http.StatusInternalServerError)
+				return
+			}
+			logEvent(h, ctx, foul.TournamentId, EventFoulMarked, foul)
+
+			if isTournamentOver(h, ctx, foul.TournamentId, *tournament) {
+				broadcastResults(h, ctx, foul.TournamentId, *tournament)
+			} else {
+				broadcastStandings(h, ctx, foul.TournamentId, *tournament)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(foul)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// The director's ruling on a disputed result. Contract, Direction,
+// DeclarerSeat and Result replace what was entered; left empty the entered
+// result stands.
+type DisputeResolution struct {
+	TournamentId string
+	BoardNumber  int
+	NSPairId     string
+	Contract     string
+	Direction    string
+	DeclarerSeat string
+	Result       string
+}
+
+func (h *Handler) DisputeHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Dispute", r.Method)
+	ctx := r.Context()
+
+	//disputes wait for the director, who alone sees and rules on them
+	if !requireDirector(w, r) {
+		return
+	}
+
+	switch r.Method {
+		case "GET":
+			tournamentId := r.URL.Query().Get("tournamentId")
+			pending, err := h.Store.GetPendingResults(ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Failed to retrieve disputed results", http.StatusInternalServerError)
+				return
+			}
+
+			disputes := []types.PendingResult{}
+			for _, res := range pending {
+				if res.Status == ResultDisputed {
+					disputes = append(disputes, res)
+				}
+			}
+			sort.Slice(disputes, func(i, j int) bool {
+				if disputes[i].BoardNumber == disputes[j].BoardNumber {
+					return disputes[i].NSPairId < disputes[j].NSPairId
+				}
+				return disputes[i].BoardNumber < disputes[j].BoardNumber
+			})
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(disputes)
+
+		case "POST":
+			var resolution DisputeResolution
+			err := json.NewDecoder(r.Body).Decode(&resolution)
+			if err != nil {
+				http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+				return
+			}
+
+			pending, err := h.Store.GetPendingResult(ctx, resolution.TournamentId, resolution.BoardNumber, resolution.NSPairId)
+			if err != nil || pending.Status != ResultDisputed {
+				http.Error(w, "No disputed result on that table", http.StatusNotFound)
+				return
+			}
+
+			result := pending.BoardResult
+			if resolution.Contract != "" {
+				result.Contract = resolution.Contract
+				result.Direction = resolution.Direction
+				result.DeclarerSeat = resolution.DeclarerSeat
+				result.Result = resolution.Result
+			}
+			err = scoreResult(&result)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			removed, err := h.Store.DeletePendingResult(ctx, result.TournamentId, result.BoardNumber, result.NSPairId)
+			if err != nil {
+				http.Error(w, "Failed to clear disputed result", http.StatusInternalServerError)
+				return
+			}
+			//a second ruling sent at the same time finds the result already taken
+			if !removed {
+				submissionError(w, rejectSubmission(CodeResultRecorded, "A result is already recorded for board %d of pair %s", result.BoardNumber, result.NSPairId))
+				return
+			}
+			response, err := recordResult(h, ctx, result)
+			if err != nil {
+				http.Error(w, "Failed to record result", http.StatusInternalServerError)
+				return
+			}
+			logEvent(h, ctx, result.TournamentId, EventResultSubmitted, result)
+			fmt.Printf("Director ruled on board %d: %+v\n", result.BoardNumber, result)
+			h.WebSocketHub.Broadcast(result.TournamentId, map[string]interface{}{
+				"Type":   "ResultConfirmed",
+				"Result": result,
+				"States": response,
+			})
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(response)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+const (
+	AuditEdit   = "Edit"
+	AuditDelete = "Delete"
+)
+
+// A director changing or removing a recorded result. Contract, Direction and
+// Result are only needed for an edit. The audit names the director by the
+// secret the request carries.
+type ResultCorrection struct {
+	TournamentId string
+	BoardNumber  int
+	NSPairId     string
+	Contract     string
+	Direction    string
+	DeclarerSeat string
+	Result       string
+	Reason       string
+}
+
+func applyCorrection(h *Handler, ctx context.Context, entry types.AuditEntry) error {
+	if entry.After != nil {
+		return SetBoardResult(h, ctx, *entry.After)
+	}
+	before := entry.Before
+	err := h.Store.DeleteBoardResult(ctx, before.TournamentId, before.BoardNumber, before.NSPairId)
+	if err != nil {
+		return err
+	}
+	//the board no longer counts for either pair
+	for _, pairId := range []string{before.NSPairId, before.EWPairId} {
+		err = h.Store.DeletePairBoardResult(ctx, before.TournamentId, pairId, before.BoardNumber)
+		if err != nil {
+			fmt.Println("Unable to remove board", before.BoardNumber, "from pair", pairId, err)
+		}
+	}
+	return nil
+}
+
+// Neither pair moves when a result is corrected. Once the tournament is over
+// the leaderboard is recalculated and broadcast again.
+func (h *Handler) CorrectionHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Correction", r.Method)
+	ctx := r.Context()
+
+	if r.Method != "PUT" && r.Method != "DELETE" {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireDirector(w, r) {
+		return
+	}
+	director, _ := directorName(r)
+
+	var correction ResultCorrection
+	err := json.NewDecoder(r.Body).Decode(&correction)
+	if err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	tournament, err := GetTournamentById(h, ctx, correction.TournamentId)
+	if err != nil {
+		http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+		return
+	}
+	before, err := h.Store.GetBoardResult(ctx, correction.TournamentId, correction.BoardNumber, correction.NSPairId)
+	if errors.Is(err, database.ErrNotFound) {
+		http.Error(w, fmt.Sprintf("No result for pair %s on board %d", correction.NSPairId, correction.BoardNumber), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get result", http.StatusInternalServerError)
+		return
+	}
+
+	entry := types.AuditEntry{
+		TournamentId: correction.TournamentId,
+		Time:         time.Now(),
+		Director:     director,
+		BoardNumber:  correction.BoardNumber,
+		NSPairId:     correction.NSPairId,
+		Before:       before,
+		Reason:       correction.Reason,
+	}
+	switch r.Method {
+		case "PUT":
+			after := *before
+			after.Contract = correction.Contract
+			after.Direction = correction.Direction
+			after.DeclarerSeat = correction.DeclarerSeat
+			after.Result = correction.Result
+			err = scoreResult(&after)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			//the board stays in whichever deal group the director put it in
+			after.FoulGroup = before.FoulGroup
+			entry.Action = AuditEdit
+			entry.After = &after
+		case "DELETE":
+			entry.Action = AuditDelete
+	}
+	err = applyCorrection(h, ctx, entry)
+	if err != nil {
+		http.Error(w, "Failed to correct result", http.StatusInternalServerError)
+		return
+	}
+	logEvent(h, ctx, entry.TournamentId, EventResultCorrected, entry)
+	sendTraveller(h, ctx, entry.TournamentId, entry.BoardNumber)
+
+	err = h.Store.AddAuditEntry(ctx, entry)
+	if err != nil {
+		fmt.Println("Unable to write audit entry:", err)
+	}
+	fmt.Printf("%s changed board %d of pair %s: %+v\n", entry.Director, entry.BoardNumber, entry.NSPairId, entry)
+
+	h.WebSocketHub.Broadcast(correction.TournamentId, map[string]interface{}{
+		"Type":  "ResultCorrected",
+		"Audit": entry,
+	})
+	if isTournamentOver(h, ctx, correction.TournamentId, *tournament) {
+		broadcastResults(h, ctx, correction.TournamentId, *tournament)
+	} else {
+		broadcastStandings(h, ctx, correction.TournamentId, *tournament)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (h *Handler) AuditHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Audit", r.Method)
+	ctx := r.Context()
+
+	if !requireDirector(w, r) {
+		return
+	}
+
+	switch r.Method {
+		case "GET":
+			tournamentId := r.URL.Query().Get("tournamentId")
+			entries, err := h.Store.GetAuditLog(ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Failed to retrieve audit log", http.StatusInternalServerError)
+				return
+			}
+			if entries == nil {
+				entries = []types.AuditEntry{}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(entries)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"src/types"
+	"src/util/movement"
+	"time"
+)
+
+const (
+	EventTournamentCreated = "TournamentCreated"
+	EventPairJoined        = "PairJoined"
+	EventTeamJoined        = "TeamJoined"
+	EventResultSubmitted   = "ResultSubmitted"
+	EventResultCorrected   = "ResultCorrected"
+	EventAdjustmentApplied = "AdjustmentApplied"
+	EventFoulMarked        = "FoulMarked"
+	EventRolledBack        = "RolledBack"
+)
+
+// Undoes every event after Seq. Seq has to be an event still in force, so
+// events an earlier rollback undid cannot be rolled back to, and events
+// logged since that rollback are only undone by naming one before them.
+// The rollback is itself an event, so nothing is ever removed from the log.
+type Rollback struct {
+	TournamentId string
+	Seq          int
+	Director     string //named by the secret the request carries, not the payload
+	Reason       string
+}
+
+// Commands are logged after they succeed, a failed append is only reported
+// since the change itself has already been made.
+func logEvent(h *Handler, ctx context.Context, tournamentId string, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		fmt.Println("Unable to encode", eventType, "event:", err)
+		return
+	}
+	_, err = h.Store.AppendEvent(ctx, types.Event{
+		TournamentId: tournamentId,
+		Type:         eventType,
+		Time:         time.Now(),
+		Data:         payload,
+	})
+	if err != nil {
+		fmt.Println("Unable to log", eventType, "event:", err)
+	}
+}
+
+// The events still in force once every rollback has been applied.
+func effectiveEvents(events []types.Event) ([]types.Event, error) {
+	var effective []types.Event
+	for _, event := range events {
+		if event.Type != EventRolledBack {
+			effective = append(effective, event)
+			continue
+		}
+		var rollback Rollback
+		err := json.Unmarshal(event.Data, &rollback)
+		if err != nil {
+			return nil, fmt.Errorf("bad rollback event %d: %w", event.Seq, err)
+		}
+		var kept []types.Event
+		for _, e := range effective {
+			if e.Seq <= rollback.Seq {
+				kept = append(kept, e)
+			}
+		}
+		effective = kept
+	}
+	return effective, nil
+}
+
+func applyEvent(h *Handler, ctx context.Context, event types.Event) error {
+	switch event.Type {
+		case EventTournamentCreated:
+			var tournament Tournament
+			err := json.Unmarshal(event.Data, &tournament)
+			if err != nil {
+				return err
+			}
+			return h.Store.SetTournament(ctx, tournament)
+
+		case EventPairJoined:
+			var pair Pair
+			err := json.Unmarshal(event.Data, &pair)
+			if err != nil {
+				return err
+			}
+			tournament, schedule, err := tournamentSchedule(h, ctx, pair.TournamentId)
+			if err != nil {
+				return err
+			}
+			//keeps the numbering in step for pairs registering after the replay
+			_, err = h.Store.NextPairNumber(ctx, pair.TournamentId)
+			if err != nil {
+				return err
+			}
+			err = h.Store.SetPair(ctx, pair)
+			if err != nil {
+				return err
+			}
+			return seatPair(h, ctx, *tournament, schedule, pair)
+
+		case EventTeamJoined:
+			var team Team
+			err := json.Unmarshal(event.Data, &team)
+			if err != nil {
+				return err
+			}
+			tournament, schedule, err := tournamentSchedule(h, ctx, team.TournamentId)
+			if err != nil {
+				return err
+			}
+			_, err = h.Store.NextTeamNumber(ctx, team.TournamentId)
+			if err != nil {
+				return err
+			}
+			err = h.Store.SetTeam(ctx, team)
+			if err != nil {
+				return err
+			}
+			return seatTeam(h, ctx, *tournament, schedule, team)
+
+		case EventResultSubmitted:
+			var result types.BoardResult
+			err := json.Unmarshal(event.Data, &result)
+			if err != nil {
+				return err
+			}
+			_, err = recordResult(h, ctx, result)
+			return err
+
+		case EventResultCorrected:
+			var entry types.AuditEntry
+			err := json.Unmarshal(event.Data, &entry)
+			if err != nil {
+				return err
+			}
+			return applyCorrection(h, ctx, entry)
+
+		case EventAdjustmentApplied:
+			var result types.BoardResult
+			err := json.Unmarshal(event.Data, &result)
+			if err != nil {
+				return err
+			}
+			_, err = applyAdjustment(h, ctx, result)
+			return err
+
+		case EventFoulMarked:
+			var foul FoulGroups
+			err := json.Unmarshal(event.Data, &foul)
+			if err != nil {
+				return err
+			}
+			return applyFoulGroups(h, ctx, foul)
+	}
+	return fmt.Errorf("unknown event type %s", event.Type)
+}
+
+func tournamentSchedule(h *Handler, ctx context.Context, tournamentId string) (*Tournament, *movement.Schedule, error) {
+	tournament, err := GetTournamentById(h, ctx, tournamentId)
+	if err != nil {
+		return nil, nil, err
+	}
+	schedule, err := GetSchedule(*tournament)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tournament, schedule, nil
+}
+
+// Rebuilds pair states, results and leaderboards of a tournament from its
+// event log.
+func ReplayTournament(h *Handler, ctx context.Context, tournamentId string) error {
+	events, err := h.Store.GetEvents(ctx, tournamentId)
+	if err != nil {
+		return err
+	}
+	effective, err := effectiveEvents(events)
+	if err != nil {
+		return err
+	}
+	if len(effective) == 0 || effective[0].Type != EventTournamentCreated {
+		return errors.New("tournament has no event log to replay")
+	}
+
+	err = h.Store.ResetTournament(ctx, tournamentId)
+	if err != nil {
+		return fmt.Errorf("failed to reset tournament: %w", err)
+	}
+	//nothing is broadcast while the log is replayed
+	replayer := &Handler{Store: h.Store, WebSocketHub: NewWebSocketHub()}
+	for _, event := range effective {
+		err = applyEvent(replayer, ctx, event)
+		if err != nil {
+			return fmt.Errorf("failed to replay event %d (%s): %w", event.Seq, event.Type, err)
+		}
+	}
+	fmt.Println("Replayed", len(effective), "of", len(events), "events of tournament", tournamentId)
+
+	tournament, err := GetTournamentById(h, ctx, tournamentId)
+	if err != nil {
+		return err
+	}
+	if isTournamentOver(h, ctx, tournamentId, *tournament) {
+		broadcastResults(h, ctx, tournamentId, *tournament)
+	}
+	return nil
+}
+
+func (h *Handler) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Events", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "GET":
+			tournamentId := r.URL.Query().Get("tournamentId")
+			_, err := GetTournamentById(h, ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+			events, err := h.Store.GetEvents(ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
+				return
+			}
+			if events == nil {
+				events = []types.Event{}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(events)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) RollbackHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Rollback", r.Method)
+	ctx := r.Context()
+
+	if r.Method != "POST" {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireDirector(w, r) {
+		return
+	}
+
+	var rollback Rollback
+	err := json.NewDecoder(r.Body).Decode(&rollback)
+	if err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	rollback.Director, _ = directorName(r)
+	_, err = GetTournamentById(h, ctx, rollback.TournamentId)
+	if err != nil {
+		http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+		return
+	}
+
+	events, err := h.Store.GetEvents(ctx, rollback.TournamentId)
+	if err != nil {
+		http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
+		return
+	}
+	effective, err := effectiveEvents(events)
+	if err != nil {
+		http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
+		return
+	}
+	//the tournament has to still exist after the rollback, which the first event in force creates
+	inForce := false
+	for _, event := range effective {
+		inForce = inForce || event.Seq == rollback.Seq
+	}
+	if !inForce {
+		http.Error(w, "No such event in force to roll back to", http.StatusBadRequest)
+		return
+	}
+
+	payload, err := json.Marshal(rollback)
+	if err != nil {
+		http.Error(w, "Failed to encode rollback", http.StatusInternalServerError)
+		return
+	}
+	event, err := h.Store.AppendEvent(ctx, types.Event{
+		TournamentId: rollback.TournamentId,
+		Type:         EventRolledBack,
+		Time:         time.Now(),
+		Data:         payload,
+	})
+	if err != nil {
+		http.Error(w, "Failed to log rollback", http.StatusInternalServerError)
+		return
+	}
+
+	err = ReplayTournament(h, ctx, rollback.TournamentId)
+	if err != nil {
+		fmt.Println("Replay failed:", err)
+		http.Error(w, "Failed to replay tournament", http.StatusInternalServerError)
+		return
+	}
+	fmt.Printf("%s rolled tournament %s back to event %d\n", rollback.Director, rollback.TournamentId, rollback.Seq)
+
+	h.WebSocketHub.Broadcast(rollback.TournamentId, map[string]interface{}{
+		"Type":  "RolledBack",
+		"Event": event,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(event)
+}
@@ -0,0 +1,331 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"src/util/dealer"
+	"src/util/pbn"
+	"src/util/scoring"
+	"src/util/usebio"
+	"strings"
+)
+
+// The date the tournament was created on, from its event log, in the
+// layout the export wants.
+func sessionDate(h *Handler, ctx context.Context, tournamentId string, layout string) string {
+	events, err := h.Store.GetEvents(ctx, tournamentId)
+	if err != nil || len(events) == 0 || events[0].Type != EventTournamentCreated {
+		return ""
+	}
+	return events[0].Time.Format(layout)
+}
+
+// Every board the movement plays, with its hands and travellers.
+func buildSession(h *Handler, ctx context.Context, tournament Tournament) (*pbn.Session, error) {
+	expectedResults, err := GetExpectedResultsByBoard(tournament)
+	if err != nil {
+		return nil, fmt.Errorf("could not build movement: %w", err)
+	}
+	var boardNumbers []int
+	for boardNumber := range expectedResults {
+		boardNumbers = append(boardNumbers, boardNumber)
+	}
+	sort.Ints(boardNumbers)
+
+	session := &pbn.Session{
+		Event: tournament.Id,
+		Date:  sessionDate(h, ctx, tournament.Id, "2006.01.02"),
+		IMPs:  tournament.ScoringMethod != ScoringMatchpoints,
+	}
+	for _, boardNumber := range boardNumbers {
+		traveller, err := buildTraveller(h, ctx, tournament, boardNumber)
+		if err != nil {
+			return nil, err
+		}
+		game := pbn.Game{
+			BoardNumber: boardNumber,
+			Dealer:      traveller.Dealer,
+			Vulnerable:  traveller.Vulnerability,
+			Deal:        traveller.Deal,
+		}
+		for _, line := range traveller.Lines {
+			game.Scores = append(game.Scores, pbn.ScoreLine{
+				NSPairId:      line.NSPairId,
+				EWPairId:      line.EWPairId,
+				Contract:      line.Contract,
+				Declarer:      line.DeclarerSeat,
+				Tricks:        line.Tricks,
+				NSScore:       line.NSScore,
+				EWScore:       line.EWScore,
+				NSMatchpoints: line.NSMatchpoints,
+				EWMatchpoints: line.EWMatchpoints,
+				NSPercentage:  line.NSPercentage,
+				EWPercentage:  line.EWPercentage,
+				NSIMPs:        line.NSIMPs,
+				EWIMPs:        line.EWIMPs,
+			})
+		}
+		session.Games = append(session.Games, game)
+	}
+	return session, nil
+}
+
+func (h *Handler) PBNExportHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle PBN Export", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "GET":
+			//the hands are in the file, so not before every board has been played
+			if !requireDirector(w, r) {
+				return
+			}
+			tournamentId := r.URL.Query().Get("tournamentId")
+			tournament, err := GetTournamentById(h, ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+			if !isTournamentOver(h, ctx, tournamentId, *tournament) {
+				http.Error(w, "Tournament is not finished", http.StatusConflict)
+				return
+			}
+			session, err := buildSession(h, ctx, *tournament)
+			if err != nil {
+				http.Error(w, "Failed to build session", http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.pbn\"", tournamentId))
+			err = pbn.Write(w, *session)
+			if err != nil {
+				fmt.Println("Unable to write PBN export:", err)
+			}
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Printable hand records of the stored deals, for the boards to be checked
+// against after play.
+func (h *Handler) HandRecordsHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Hand Records", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "GET":
+			if !requireDirector(w, r) {
+				return
+			}
+			tournamentId := r.URL.Query().Get("tournamentId")
+			tournament, err := GetTournamentById(h, ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+			if !isTournamentOver(h, ctx, tournamentId, *tournament) {
+				http.Error(w, "Tournament is not finished", http.StatusConflict)
+				return
+			}
+			deals, err := h.Store.GetDeals(ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Failed to get deals", http.StatusInternalServerError)
+				return
+			}
+			sort.Slice(deals, func(i, j int) bool { return deals[i].BoardNumber < deals[j].BoardNumber })
+
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			err = dealer.WriteHandRecords(w, tournamentId, deals)
+			if err != nil {
+				fmt.Println("Unable to write hand records:", err)
+			}
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Pair ids carry the direction in a Mitchell, USEBIO keeps it apart.
+func pairNumber(pairId string) (string, string) {
+	for _, direction := range []string{"NS", "EW"} {
+		if number, ok := strings.CutSuffix(pairId, direction); ok {
+			return number, direction
+		}
+	}
+	return pairId, ""
+}
+
+func decimal(value float64) *usebio.Decimal {
+	d := usebio.Decimal(value)
+	return &d
+}
+
+// The final ranking, players and every board's traveller as one USEBIO event.
+func buildUSEBIO(h *Handler, ctx context.Context, tournament Tournament) (*usebio.Event, error) {
+	standings, err := calculateStandings(h, ctx, tournament.Id, tournament)
+	if err != nil {
+		return nil, err
+	}
+	imps := tournament.ScoringMethod != ScoringMatchpoints
+	event := &usebio.Event{
+		Type:          usebio.EventMPPairs,
+		Description:   tournament.Id,
+		ProgramName:   "bridge-tournament-restapi",
+		Date:          sessionDate(h, ctx, tournament.Id, "02/01/2006"),
+		ScoringMethod: usebio.ScoringMatchPoints,
+		WinnerType:    2,
+	}
+	switch tournament.ScoringMethod {
+		case ScoringCrossIMPs:
+			event.Type, event.ScoringMethod = usebio.EventCrossIMPPairs, usebio.ScoringCrossIMPs
+		case ScoringButler:
+			event.Type, event.ScoringMethod = usebio.EventButlerPairs, usebio.ScoringButler
+	}
+	if isSingleField(tournament) {
+		event.WinnerType = 1
+	}
+
+	//Overall, or NS then EW
+	var sections []string
+	for section := range standings.Sections {
+		sections = append(sections, section)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(sections)))
+	for _, section := range sections {
+		sortedResults := standings.Sections[section]
+		//pairs on the same score share a place
+		shared := func(i int, j int) bool {
+			if j < 0 || j >= len(sortedResults) {
+				return false
+			}
+			if imps {
+				return sortedResults[i].Score.IMPs == sortedResults[j].Score.IMPs
+			}
+			return sortedResults[i].Score.Percentage == sortedResults[j].Score.Percentage
+		}
+		place := 0
+		for i, res := range sortedResults {
+			if !shared(i, i-1) {
+				place = i + 1
+			}
+			total := res.Score.MPScore
+			if imps {
+				total = res.Score.IMPs
+			}
+
+			number, direction := pairNumber(res.PairId)
+			pair := usebio.Pair{
+				Number:     number,
+				Direction:  direction,
+				Place:      fmt.Sprintf("%d", place),
+				TotalScore: usebio.Decimal(total),
+			}
+			if shared(i, i-1) || shared(i, i+1) {
+				pair.Place += "="
+			}
+			if !imps {
+				pair.Percentage = decimal(res.Score.Percentage)
+			}
+			for _, name := range []string{res.Name1, res.Name2} {
+				if name != "" {
+					pair.Players = append(pair.Players, usebio.Player{Name: name})
+				}
+			}
+			event.Participants = append(event.Participants, pair)
+		}
+	}
+
+	expectedResults, err := GetExpectedResultsByBoard(tournament)
+	if err != nil {
+		return nil, fmt.Errorf("could not build movement: %w", err)
+	}
+	var boardNumbers []int
+	for boardNumber := range expectedResults {
+		boardNumbers = append(boardNumbers, boardNumber)
+	}
+	sort.Ints(boardNumbers)
+	for _, boardNumber := range boardNumbers {
+		traveller, err := buildTraveller(h, ctx, tournament, boardNumber)
+		if err != nil {
+			return nil, err
+		}
+		if len(traveller.Lines) == 0 {
+			continue
+		}
+		board := usebio.Board{Number: boardNumber}
+		for _, line := range traveller.Lines {
+			nsNumber, _ := pairNumber(line.NSPairId)
+			ewNumber, _ := pairNumber(line.EWPairId)
+			result := usebio.TravellerLine{
+				NSPairNumber: nsNumber,
+				EWPairNumber: ewNumber,
+				Adjustment:   line.Adjustment,
+			}
+			//PLAYED_BY is left out when the table did not enter the declarer's seat
+			if line.Adjustment == "" {
+				result.Contract = line.Contract
+				if !strings.EqualFold(line.Contract, scoring.PassedOut) {
+					result.PlayedBy = line.DeclarerSeat
+					tricks := line.Tricks
+					result.Tricks = &tricks
+				}
+			}
+			//an artificial score has no table score, only matchpoints or IMPs
+			if line.Adjustment != "Artificial" {
+				score := line.NSScore
+				result.Score = &score
+			}
+			if imps {
+				result.NSIMPs, result.EWIMPs = decimal(line.NSIMPs), decimal(line.EWIMPs)
+			} else {
+				result.NSMatchPoints, result.EWMatchPoints = decimal(line.NSMatchpoints), decimal(line.EWMatchpoints)
+			}
+			board.Lines = append(board.Lines, result)
+		}
+		event.Boards = append(event.Boards, board)
+	}
+	return event, nil
+}
+
+// USEBIO results of a finished pairs tournament, for submitting to a federation.
+func (h *Handler) USEBIOExportHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle USEBIO Export", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "GET":
+			tournamentId := r.URL.Query().Get("tournamentId")
+			tournament, err := GetTournamentById(h, ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+			if tournament.Type == TeamGame {
+				http.Error(w, "USEBIO export is for pair games", http.StatusBadRequest)
+				return
+			}
+			if !isTournamentOver(h, ctx, tournamentId, *tournament) {
+				http.Error(w, "Tournament is not finished", http.StatusConflict)
+				return
+			}
+			event, err := buildUSEBIO(h, ctx, *tournament)
+			if err != nil {
+				http.Error(w, "Failed to build results", http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.xml\"", tournamentId))
+			err = usebio.Write(w, *event)
+			if err != nil {
+				fmt.Println("Unable to write USEBIO export:", err)
+			}
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"src/types"
+)
+
+// A tournament together with its final ranking.
+type TournamentRecord struct {
+	Tournament  Tournament
+	Leaderboard []types.LeaderboardEntry
+}
+
+func saveLeaderboard(h *Handler, ctx context.Context, tournamentId string, sections map[string][]SortedResult) error {
+	var entries []types.LeaderboardEntry
+	for section, sortedResults := range sections {
+		for i, res := range sortedResults {
+			entries = append(entries, types.LeaderboardEntry{
+				Section:    section,
+				Rank:       i + 1,
+				PairId:     res.PairId,
+				Name1:      res.Name1,
+				Name2:      res.Name2,
+				MPScore:    res.Score.MPScore,
+				Percentage: res.Score.Percentage,
+				IMPs:       res.Score.IMPs,
+			})
+		}
+	}
+	return h.Store.SetLeaderboard(ctx, tournamentId, entries)
+}
+
+func saveTeamStandings(h *Handler, ctx context.Context, tournamentId string, standings []TeamStanding) error {
+	var entries []types.LeaderboardEntry
+	for i, standing := range standings {
+		entries = append(entries, types.LeaderboardEntry{
+			Section: "Teams",
+			Rank:    i + 1,
+			PairId:  standing.TeamId,
+			Name1:   standing.Name,
+			IMPs:    float64(standing.IMPs),
+			VPs:     standing.VPs,
+		})
+	}
+	return h.Store.SetLeaderboard(ctx, tournamentId, entries)
+}
+
+// Finished tournaments are the ones with a final ranking.
+func (h *Handler) HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle History", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "GET":
+			var tournaments []Tournament
+			if tournamentId := r.URL.Query().Get("tournamentId"); tournamentId != "" {
+				tournament, err := GetTournamentById(h, ctx, tournamentId)
+				if err != nil {
+					http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+					return
+				}
+				tournaments = append(tournaments, *tournament)
+			} else {
+				var err error
+				tournaments, err = h.Store.ListTournaments(ctx)
+				if err != nil {
+					http.Error(w, "Failed to list tournaments", http.StatusInternalServerError)
+					return
+				}
+			}
+
+			records := []TournamentRecord{}
+			for _, tournament := range tournaments {
+				leaderboard, err := h.Store.GetLeaderboard(ctx, tournament.Id)
+				if err != nil {
+					http.Error(w, "Failed to retrieve leaderboard", http.StatusInternalServerError)
+					return
+				}
+				if len(leaderboard) == 0 {
+					continue
+				}
+				sort.SliceStable(leaderboard, func(i, j int) bool {
+					if leaderboard[i].Section == leaderboard[j].Section {
+						return leaderboard[i].Rank < leaderboard[j].Rank
+					}
+					return leaderboard[i].Section < leaderboard[j].Section
+				})
+				records = append(records, TournamentRecord{Tournament: tournament, Leaderboard: leaderboard})
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(records)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
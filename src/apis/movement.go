@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"src/util/movement"
+)
+
+// GetMovement picks the movement a tournament was set up with. A plain
+// Mitchell with an even number of tables and more rounds than half the
+// tables skips, as it always has. An odd field gets a sit-out each round.
+func GetMovement(tournament Tournament) (movement.Movement, error) {
+	mov, err := getBaseMovement(tournament)
+	if err != nil || len(tournament.ArrowSwitchRounds) == 0 || tournament.Type == TeamGame {
+		return mov, err
+	}
+	return movement.ArrowSwitch{
+		Movement: mov,
+		Rounds:   tournament.ArrowSwitchRounds,
+	}, nil
+}
+
+// Whether every pair is ranked in one list rather than by direction.
+func isSingleField(tournament Tournament) bool {
+	return tournament.Movement == MovementHowell || len(tournament.ArrowSwitchRounds) > 0
+}
+
+func getBaseMovement(tournament Tournament) (movement.Movement, error) {
+	if tournament.Type == TeamGame {
+		return movement.TeamRoundRobin{
+			Teams:          tournament.Teams,
+			BoardsPerRound: tournament.BoardsPerRound,
+			Rounds:         tournament.TotalRounds,
+		}, nil
+	}
+
+	tables := (tournament.Teams + 1) / 2
+	sitOut := tournament.Teams%2 == 1
+	switch tournament.Movement {
+		case MovementMitchell, MovementSkipMitchell:
+			skip := tournament.Movement == MovementSkipMitchell || (tables%2 == 0 && tournament.TotalRounds > tables/2)
+			return movement.Mitchell{
+				Tables:         tables,
+				BoardsPerRound: tournament.BoardsPerRound,
+				Rounds:         tournament.TotalRounds,
+				Skip:           skip,
+				SitOut:         sitOut,
+			}, nil
+		case MovementHowell:
+			return movement.Howell{
+				Tables:         tables,
+				BoardsPerRound: tournament.BoardsPerRound,
+				Rounds:         tournament.TotalRounds,
+				SitOut:         sitOut,
+			}, nil
+		case MovementRelayMitchell:
+			return movement.RelayMitchell{
+				Tables:         tables,
+				BoardsPerRound: tournament.BoardsPerRound,
+				Rounds:         tournament.TotalRounds,
+				SitOut:         sitOut,
+			}, nil
+		default:
+			return nil, fmt.Errorf("unknown movement %d", tournament.Movement)
+	}
+}
+
+func GetSchedule(tournament Tournament) (*movement.Schedule, error) {
+	mov, err := GetMovement(tournament)
+	if err != nil {
+		return nil, err
+	}
+	return mov.Schedule()
+}
+
+// Direction of a pair in a round. Howell and arrow-switched pairs change
+// direction, so this comes from the movement rather than the pair id.
+func GetPairDirection(tournament Tournament, pairId string, round int) (string, error) {
+	schedule, err := GetSchedule(tournament)
+	if err != nil {
+		return "", err
+	}
+	seat, err := schedule.FindSeat(round, pairId)
+	if err != nil {
+		return "", err
+	}
+	return seat.Direction(pairId), nil
+}
+
+// A pair sitting out has no board and no opponent for the round.
+func boardStateFromSeat(seat *movement.Seat, pairId string, round int, board int) *BoardState {
+	if seat.IsBye() {
+		return &BoardState{
+			CurrentRound: round,
+			Direction:    seat.Direction(pairId),
+			IsSitOut:     true,
+		}
+	}
+	return &BoardState{
+		CurrentBoard: board,
+		CurrentOpp:   seat.Opponent(pairId),
+		CurrentRound: round,
+		Direction:    seat.Direction(pairId),
+		Room:         seat.Room,
+	}
+}
+
+// Seats a pair that has just registered at its round 1 table.
+func seatPair(h *Handler, ctx context.Context, tournament Tournament, schedule *movement.Schedule, pair Pair) error {
+	seat, err := schedule.FindSeat(1, pair.Id)
+	if err != nil {
+		return err
+	}
+	boardState := boardStateFromSeat(seat, pair.Id, 1, seat.Boards[0])
+	err = SetBoardState(h, ctx, pair.TournamentId, pair.Id, *boardState)
+	if err != nil {
+		return err
+	}
+	if boardState.IsSitOut {
+		fmt.Println("Pair", pair.Id, "sits out the first round")
+		_, err, _ = settleSitOut(h, ctx, pair.TournamentId, pair.Id, boardState, tournament, schedule)
+	}
+	return err
+}
+
+// Moves a pair on to the next board of its seat, or to its seat in the next
+// round once the board set is finished. Returns true when the pair has
+// played its last round.
+func calculateNextState(boardState BoardState, pairId string, schedule *movement.Schedule) (*BoardState, bool, error) {
+	seat, err := schedule.FindSeat(boardState.CurrentRound, pairId)
+	if err != nil {
+		return nil, false, err
+	}
+	for i, board := range seat.Boards {
+		if board == boardState.CurrentBoard && i+1 < len(seat.Boards) {
+			return boardStateFromSeat(seat, pairId, boardState.CurrentRound, seat.Boards[i+1]), false, nil
+		}
+	}
+
+	nextRound := boardState.CurrentRound + 1
+	if nextRound > len(schedule.Rounds) {
+		return nil, true, nil
+	}
+	seat, err = schedule.FindSeat(nextRound, pairId)
+	if err != nil {
+		return nil, false, err
+	}
+	return boardStateFromSeat(seat, pairId, nextRound, seat.Boards[0]), false, nil
+}
+
+// A pair sitting out a round moves on as soon as its next opponent reaches
+// that round, and is done straight away when it sits out the last round.
+func settleSitOut(h *Handler, ctx context.Context, tournamentId string, pairId string, boardState *BoardState, tournament Tournament, schedule *movement.Schedule) (*BoardState, error, bool) {
+	if boardState.CurrentRound >= len(schedule.Rounds) {
+		return nil, finishPair(h, ctx, tournamentId, pairId, tournament), true
+	}
+	seat, err := schedule.FindSeat(boardState.CurrentRound+1, pairId)
+	if err != nil {
+		return nil, err, false
+	}
+	if seat.IsBye() {
+		return boardState, nil, false
+	}
+	oppState, err := GetBoardStateByPairId(h, ctx, tournamentId, seat.Opponent(pairId))
+	if err != nil || oppState.CurrentRound <= boardState.CurrentRound {
+		fmt.Println("Pair", pairId, "sits out round", boardState.CurrentRound)
+		return boardState, nil, false
+	}
+	return NextState(h, ctx, tournamentId, pairId)
+}
+
+// Once a pair arrives at a new table, its opponent may have been waiting out
+// the previous round.
+func releaseSitOut(h *Handler, ctx context.Context, tournamentId string, boardState *BoardState) {
+	oppState, err := GetBoardStateByPairId(h, ctx, tournamentId, boardState.CurrentOpp)
+	if err != nil || !oppState.IsSitOut || oppState.CurrentRound != boardState.CurrentRound-1 {
+		return
+	}
+	newOppState, err, isOver := NextState(h, ctx, tournamentId, boardState.CurrentOpp)
+	if err != nil {
+		fmt.Println("Unable to move pair", boardState.CurrentOpp, "on from sitting out:", err)
+		return
+	}
+	h.WebSocketHub.Broadcast(tournamentId, map[string]interface{}{
+		"Type": "SitOutOver",
+		"PairId": boardState.CurrentOpp,
+		"BoardState": newOppState,
+		"IsOver": isOver,
+	})
+}
+
+// How many times each board is scheduled to be played, which is the top
+// every board is factored to.
+func GetExpectedResultsByBoard(tournament Tournament) (map[int]int, error) {
+	schedule, err := GetSchedule(tournament)
+	if err != nil {
+		return nil, err
+	}
+	expected := make(map[int]int)
+	for _, seats := range schedule.Rounds {
+		for _, seat := range seats {
+			if seat.IsBye() {
+				continue
+			}
+			for _, board := range seat.Boards {
+				expected[board]++
+			}
+		}
+	}
+	return expected, nil
+}
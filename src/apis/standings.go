@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"src/database"
+)
+
+// The ranking on the results recorded so far. A pair game is ranked in
+// sections, a team game by team.
+type Standings struct {
+	TournamentId string
+	AsOfRound    int  //last round every table has finished
+	Final        bool //every pair has finished
+	Sections     map[string][]SortedResult
+	Teams        []TeamStanding
+}
+
+// The last round every pair has played out. A pair sitting out has nothing
+// left to play in its round, and nothing is complete until everyone has
+// registered.
+func completedRound(h *Handler, ctx context.Context, tournamentId string, tournament Tournament) (int, error) {
+	mov, err := GetMovement(tournament)
+	if err != nil {
+		return 0, err
+	}
+	round := tournament.TotalRounds
+	for _, pairId := range mov.Pairs() {
+		if isPairFinished(h, ctx, tournamentId, pairId) {
+			continue
+		}
+		state, err := h.Store.GetBoardState(ctx, tournamentId, pairId)
+		if errors.Is(err, database.ErrNotFound) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		played := state.CurrentRound - 1
+		if state.IsSitOut {
+			played = state.CurrentRound
+		}
+		if played < round {
+			round = played
+		}
+	}
+	return round, nil
+}
+
+func calculateStandings(h *Handler, ctx context.Context, tournamentId string, tournament Tournament) (*Standings, error) {
+	results, err := GetBoardResults(h, ctx, tournamentId)
+	if err != nil {
+		return nil, err
+	}
+	round, err := completedRound(h, ctx, tournamentId, tournament)
+	if err != nil {
+		return nil, fmt.Errorf("unable to work out the completed round: %w", err)
+	}
+	standings := &Standings{
+		TournamentId: tournamentId,
+		AsOfRound:    round,
+		Final:        isTournamentOver(h, ctx, tournamentId, tournament),
+	}
+
+	if tournament.Type == TeamGame {
+		standings.Teams, err = CalculateTeamStandings(h, ctx, results, tournament, tournamentId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate team standings: %w", err)
+		}
+		return standings, nil
+	}
+
+	leaderboard, err := CalculateLeaderboard(h, ctx, results, tournament, tournamentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate leaderboard: %w", err)
+	}
+	//a Howell or arrow-switched field is ranked as one, a Mitchell has separate NS and EW winners
+	standings.Sections = make(map[string][]SortedResult)
+	for pairId, score := range leaderboard {
+		name1, name2, _ := GetNamesByPairId(h, ctx, tournamentId, pairId)
+		section := "Overall"
+		if !isSingleField(tournament) {
+			section, _ = GetDirectionFromPairId(pairId)
+		}
+		standings.Sections[section] = append(standings.Sections[section], SortedResult{
+			pairId,
+			name1,
+			name2,
+			score,
+		})
+	}
+	for _, sortedResults := range standings.Sections {
+		sortLeaderboard(sortedResults, tournament)
+	}
+	return standings, nil
+}
+
+// Running standings while the tournament is in play, the final ranking goes
+// out through broadcastResults.
+func broadcastStandings(h *Handler, ctx context.Context, tournamentId string, tournament Tournament) error {
+	standings, err := calculateStandings(h, ctx, tournamentId, tournament)
+	if err != nil {
+		fmt.Println("Unable to calculate standings:", err)
+		return err
+	}
+	fmt.Printf("Standings as of round %d: %+v\n", standings.AsOfRound, standings)
+	h.WebSocketHub.Broadcast(tournamentId, map[string]interface{}{
+		"Type":      "Standings",
+		"Standings": standings,
+	})
+	return nil
+}
+
+func (h *Handler) LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Leaderboard", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "GET":
+			tournamentId := r.URL.Query().Get("tournamentId")
+			tournament, err := GetTournamentById(h, ctx, tournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+			standings, err := calculateStandings(h, ctx, tournamentId, *tournament)
+			if err != nil {
+				http.Error(w, "Failed to calculate standings", http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(standings)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"src/types"
+	"src/util/movement"
+	"src/util/scoring"
+	"strconv"
+)
+
+type Team = types.Team
+
+type TeamBoardResult struct {
+	BoardNumber int
+	OpenScore   int
+	ClosedScore int
+	IMPs        int
+}
+
+type TeamMatchResult struct {
+	Round       int
+	OppTeamId   string
+	IMPsFor     int
+	IMPsAgainst int
+	VPs         float64
+	Boards      []TeamBoardResult
+}
+
+type TeamStanding struct {
+	TeamId  string
+	Name    string
+	VPs     float64
+	IMPs    int
+	Matches []TeamMatchResult
+}
+
+// Total number of pairs taking part, two per team in a team game.
+func totalPairs(tournament Tournament) int {
+	if tournament.Type == TeamGame {
+		return tournament.Teams * 2
+	}
+	return tournament.Teams
+}
+
+func GetTeamIdFromPairId(pairId string) (string, error) {
+	if len(pairId) < 3 {
+		return "", fmt.Errorf("invalid pairId: %s", pairId)
+	}
+	return pairId[:len(pairId)-2], nil
+}
+
+// Each board is compared between the two rooms of a match. The open room
+// result has one team's NS pair against the other team's EW pair, and the
+// closed room result has the reverse.
+func CalculateTeamStandings(h *Handler, ctx context.Context, allResults []types.BoardResult, tournament Tournament, tournamentId string) ([]TeamStanding, error) {
+	resultsByBoard := make(map[int]map[string]types.BoardResult)
+	for _, res := range allResults {
+		if _, ok := resultsByBoard[res.BoardNumber]; !ok {
+			resultsByBoard[res.BoardNumber] = make(map[string]types.BoardResult)
+		}
+		resultsByBoard[res.BoardNumber][res.NSPairId] = res
+	}
+
+	matches := make(map[string]map[int]*TeamMatchResult)
+	for boardNumber, results := range resultsByBoard {
+		for _, open := range results {
+			teamId, err := GetTeamIdFromPairId(open.NSPairId)
+			if err != nil {
+				return nil, err
+			}
+			oppTeamId, err := GetTeamIdFromPairId(open.EWPairId)
+			if err != nil {
+				return nil, err
+			}
+			closed, ok := results[oppTeamId+"NS"]
+			if !ok || closed.EWPairId != teamId+"EW" {
+				fmt.Printf("Board %d for team %s has not been played in the other room yet\n", boardNumber, teamId)
+				continue
+			}
+
+			round := (boardNumber-1)/tournament.BoardsPerRound + 1
+			if _, ok := matches[teamId]; !ok {
+				matches[teamId] = make(map[int]*TeamMatchResult)
+			}
+			match, ok := matches[teamId][round]
+			if !ok {
+				match = &TeamMatchResult{Round: round, OppTeamId: oppTeamId}
+				matches[teamId][round] = match
+			}
+
+			imps := scoring.CompareRooms(open.Score, closed.Score)
+			if imps > 0 {
+				match.IMPsFor += imps
+			} else {
+				match.IMPsAgainst -= imps
+			}
+			match.Boards = append(match.Boards, TeamBoardResult{
+				BoardNumber: boardNumber,
+				OpenScore:   open.Score,
+				ClosedScore: closed.Score,
+				IMPs:        imps,
+			})
+		}
+	}
+
+	var standings []TeamStanding
+	for teamNum := 1; teamNum <= tournament.Teams; teamNum++ {
+		teamId := strconv.Itoa(teamNum)
+		team, err := GetTeamById(h, ctx, tournamentId, teamId)
+		if err != nil {
+			fmt.Println("Skipping standings for team", teamId, err)
+			continue
+		}
+		standing := TeamStanding{TeamId: teamId, Name: team.Name}
+		for _, match := range matches[teamId] {
+			sort.Slice(match.Boards, func(i, j int) bool {
+				return match.Boards[i].BoardNumber < match.Boards[j].BoardNumber
+			})
+			match.VPs, _ = scoring.VictoryPoints(match.IMPsFor-match.IMPsAgainst, len(match.Boards))
+			standing.VPs += match.VPs
+			standing.IMPs += match.IMPsFor - match.IMPsAgainst
+			standing.Matches = append(standing.Matches, *match)
+		}
+		sort.Slice(standing.Matches, func(i, j int) bool {
+			return standing.Matches[i].Round < standing.Matches[j].Round
+		})
+		fmt.Printf("Team %s has %f VPs\n", teamId, standing.VPs)
+		standings = append(standings, standing)
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].VPs == standings[j].VPs {
+			return standings[i].IMPs > standings[j].IMPs
+		}
+		return standings[i].VPs > standings[j].VPs
+	})
+	return standings, nil
+}
+
+func GetTeamById(h *Handler, ctx context.Context, tournamentId string, teamId string) (*Team, error) {
+	team, err := h.Store.GetTeam(ctx, tournamentId, teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pairId := range []string{teamId + "NS", teamId + "EW"} {
+		name1, name2, err := GetNamesByPairId(h, ctx, tournamentId, pairId)
+		if err != nil {
+			return nil, err
+		}
+		team.Pairs = append(team.Pairs, Pair{
+			Id:           pairId,
+			Name1:        name1,
+			Name2:        name2,
+			TournamentId: tournamentId,
+			TeamId:       teamId,
+		})
+	}
+	return team, nil
+}
+
+// Stores both pairs of a team and seats them for round 1.
+func seatTeam(h *Handler, ctx context.Context, tournament Tournament, schedule *movement.Schedule, team Team) error {
+	for _, pair := range team.Pairs {
+		err := h.Store.SetPair(ctx, pair)
+		if err != nil {
+			return fmt.Errorf("failed to store pair %s: %w", pair.Id, err)
+		}
+		err = seatPair(h, ctx, tournament, schedule, pair)
+		if err != nil {
+			return fmt.Errorf("could not seat pair %s: %w", pair.Id, err)
+		}
+	}
+	return nil
+}
+
+func (h *Handler) TeamHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Team", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "GET":
+			tournamentId := r.URL.Query().Get("tournamentId")
+			teamId := r.URL.Query().Get("teamId")
+
+			team, err := GetTeamById(h, ctx, tournamentId, teamId)
+			if err != nil {
+				http.Error(w, "Unable to get Team info", http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(team)
+
+		case "POST":
+			var newTeam Team
+			err := json.NewDecoder(r.Body).Decode(&newTeam)
+			if err != nil {
+				http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+				return
+			}
+			if len(newTeam.Pairs) != 2 {
+				http.Error(w, "A team needs exactly two pairs", http.StatusBadRequest)
+				return
+			}
+
+			tournament, err := GetTournamentById(h, ctx, newTeam.TournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusInternalServerError)
+				return
+			}
+			if tournament.Type != TeamGame {
+				http.Error(w, "Tournament is not a team game", http.StatusBadRequest)
+				return
+			}
+
+			teamCount, err := h.Store.NextTeamNumber(ctx, newTeam.TournamentId)
+			if err != nil {
+				http.Error(w, "Couldn't get team count", http.StatusInternalServerError)
+				return
+			}
+			if teamCount > tournament.Teams {
+				h.Store.ReleaseTeamNumber(ctx, newTeam.TournamentId)
+				http.Error(w, "Tournament is full", http.StatusForbidden)
+				return
+			}
+
+			newTeam.Id = strconv.Itoa(teamCount)
+			fmt.Println("You are the following team:", newTeam.Id)
+
+			schedule, err := GetSchedule(*tournament)
+			if err != nil {
+				h.Store.ReleaseTeamNumber(ctx, newTeam.TournamentId)
+				http.Error(w, "Could not build movement", http.StatusInternalServerError)
+				return
+			}
+			//nothing is written until both pairs have a seat and a secret
+			secrets := make(map[string]string)
+			for i, direction := range []string{"NS", "EW"} {
+				pair := &newTeam.Pairs[i]
+				pair.Id = newTeam.Id + direction
+				pair.TournamentId = newTeam.TournamentId
+				pair.TeamId = newTeam.Id
+				if _, err := schedule.FindSeat(1, pair.Id); err != nil {
+					h.Store.ReleaseTeamNumber(ctx, newTeam.TournamentId)
+					http.Error(w, "Could not seat team", http.StatusInternalServerError)
+					return
+				}
+				secret, secretHash, err := newPairSecret()
+				if err != nil {
+					h.Store.ReleaseTeamNumber(ctx, newTeam.TournamentId)
+					http.Error(w, "Could not make pair secret", http.StatusInternalServerError)
+					return
+				}
+				pair.SecretHash = secretHash
+				secrets[pair.Id] = secret
+			}
+
+			//the team goes in last, so a failed pair write leaves no team behind and
+			//the released number seats the next team over what was written
+			err = seatTeam(h, ctx, *tournament, schedule, newTeam)
+			if err != nil {
+				h.Store.ReleaseTeamNumber(ctx, newTeam.TournamentId)
+				http.Error(w, "Could not seat team", http.StatusInternalServerError)
+				return
+			}
+			err = h.Store.SetTeam(ctx, newTeam)
+			if err != nil {
+				h.Store.ReleaseTeamNumber(ctx, newTeam.TournamentId)
+				http.Error(w, "Failed to store team", http.StatusInternalServerError)
+				return
+			}
+			logEvent(h, ctx, newTeam.TournamentId, EventTeamJoined, newTeam)
+
+			//the only time the secrets are sent, each pair opens its WebSocket connection with its own
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(struct {
+				Team
+				Secrets map[string]string //by pair id
+			}{newTeam, secrets})
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
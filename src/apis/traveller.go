@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"src/types"
+	"src/util/boards"
+	"src/util/scoring"
+	"strconv"
+)
+
+// One line of a traveller, the slip that goes round with a board.
+type TravellerLine struct {
+	NSPairId      string
+	EWPairId      string
+	Contract      string
+	Declarer      string //NS or EW
+	DeclarerSeat  string //N, E, S or W, blank when the table did not enter it
+	Result        string
+	Tricks        int
+	NSScore       int
+	EWScore       int //from EW's side, so a split assigned score need not sum to zero
+	NSMatchpoints float64
+	EWMatchpoints float64
+	NSPercentage  float64
+	EWPercentage  float64
+	NSIMPs        float64
+	EWIMPs        float64
+	Adjustment    string //set for a director's score, the contract is then blank
+}
+
+type Traveller struct {
+	TournamentId  string
+	BoardNumber   int
+	Dealer        string
+	Vulnerability string
+	Deal          *types.Deal //nil when no hands were imported
+	Lines         []TravellerLine
+}
+
+func buildTraveller(h *Handler, ctx context.Context, tournament Tournament, boardNumber int) (*Traveller, error) {
+	allResults, err := GetBoardResults(h, ctx, tournament.Id)
+	if err != nil {
+		return nil, err
+	}
+	var results []types.BoardResult
+	for _, res := range allResults {
+		if res.BoardNumber == boardNumber {
+			results = append(results, res)
+		}
+	}
+
+	traveller := &Traveller{TournamentId: tournament.Id, BoardNumber: boardNumber, Lines: []TravellerLine{}}
+	if info, err := boards.Get(boardNumber); err == nil {
+		traveller.Dealer = info.Dealer
+		traveller.Vulnerability = info.VulName
+	}
+	traveller.Deal, err = getDeal(h, ctx, tournament.Id, boardNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return traveller, nil
+	}
+
+	expected := 0
+	if expectedResults, err := GetExpectedResultsByBoard(tournament); err == nil {
+		expected = expectedResults[boardNumber]
+	}
+	scores, _, err := scoreBoard(results, expected, tournament)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score board %d: %w", boardNumber, err)
+	}
+
+	for _, res := range results {
+		line := TravellerLine{
+			NSPairId:      res.NSPairId,
+			EWPairId:      res.EWPairId,
+			Contract:      res.Contract,
+			Declarer:      res.Direction,
+			DeclarerSeat:  res.DeclarerSeat,
+			Result:        res.Result,
+			NSScore:       res.Score,
+			EWScore:       -scoring.EWScore(res),
+			NSMatchpoints: scores[res.NSPairId].MPScore,
+			EWMatchpoints: scores[res.EWPairId].MPScore,
+			NSPercentage:  scores[res.NSPairId].Percentage,
+			EWPercentage:  scores[res.EWPairId].Percentage,
+			NSIMPs:        scores[res.NSPairId].IMPs,
+			EWIMPs:        scores[res.EWPairId].IMPs,
+			Adjustment:    res.Adjustment,
+		}
+		if res.Adjustment == "" {
+			line.Tricks, _ = scoring.Tricks(res.Contract, res.Result)
+		}
+		traveller.Lines = append(traveller.Lines, line)
+	}
+	//best NS score first, as on a paper traveller
+	sort.SliceStable(traveller.Lines, func(i, j int) bool {
+		return traveller.Lines[i].NSScore > traveller.Lines[j].NSScore
+	})
+	return traveller, nil
+}
+
+// The pairs that have played a board are the ones allowed to see its traveller.
+func travellerPairs(traveller *Traveller) []string {
+	var pairIds []string
+	for _, line := range traveller.Lines {
+		pairIds = append(pairIds, line.NSPairId, line.EWPairId)
+	}
+	return pairIds
+}
+
+func hasPlayed(traveller *Traveller, pairId string) bool {
+	for _, id := range travellerPairs(traveller) {
+		if id == pairId {
+			return true
+		}
+	}
+	return false
+}
+
+// Sends the updated traveller to every pair that has played the board.
+func sendTraveller(h *Handler, ctx context.Context, tournamentId string, boardNumber int) {
+	tournament, err := GetTournamentById(h, ctx, tournamentId)
+	if err != nil {
+		fmt.Println("Unable to send traveller:", err)
+		return
+	}
+	traveller, err := buildTraveller(h, ctx, *tournament, boardNumber)
+	if err != nil {
+		fmt.Println("Unable to send traveller:", err)
+		return
+	}
+	h.WebSocketHub.Send(tournamentId, travellerPairs(traveller), map[string]interface{}{
+		"Type":      "Traveller",
+		"Traveller": traveller,
+	})
+}
+
+// A pair only sees the traveller of a board it has played, the director sees
+// every board. The pair is the one whose connection the session token was
+// given to, never one named in the query.
+func (h *Handler) TravellerHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Handle Traveller", r.Method)
+	ctx := r.Context()
+
+	switch r.Method {
+		case "GET":
+			query := r.URL.Query()
+			tournament, err := GetTournamentById(h, ctx, query.Get("tournamentId"))
+			if err != nil {
+				http.Error(w, "Couldn't get tournament", http.StatusNotFound)
+				return
+			}
+			boardNumber, err := strconv.Atoi(query.Get("board"))
+			if err != nil || boardNumber < 1 {
+				http.Error(w, "Invalid board number", http.StatusBadRequest)
+				return
+			}
+			director := isDirector(r)
+			pairId, connected := requestingPair(h, r, tournament.Id)
+			if !director && !connected {
+				http.Error(w, "A pair's session token or the director secret is required", http.StatusUnauthorized)
+				return
+			}
+
+			traveller, err := buildTraveller(h, ctx, *tournament, boardNumber)
+			if err != nil {
+				http.Error(w, "Failed to build traveller", http.StatusInternalServerError)
+				return
+			}
+			if !director && !hasPlayed(traveller, pairId) {
+				http.Error(w, "The traveller is shown once the pair has played the board", http.StatusForbidden)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(traveller)
+
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
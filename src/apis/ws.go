@@ -4,17 +4,26 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"src/util"
 	"time"
 	"github.com/gorilla/websocket"
 )
 
 type WebSocketHub struct {
 	clients map[string]map[string]*websocket.Conn
+	sessions map[string]session //by the token each connection is given
 	mu sync.Mutex
 	expectedClientCounts map[string]int
 	OnClientCountChangeMap map[string]func(count int)
 }
 
+//who a connection's token stands for
+type session struct {
+	tournamentId string
+	clientId string
+	conn *websocket.Conn
+}
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		// Allow all origins for testing; tighten in production
@@ -25,6 +34,7 @@ var upgrader = websocket.Upgrader{
 func NewWebSocketHub() *WebSocketHub{
 	return &WebSocketHub{
 		clients:make(map[string]map[string]*websocket.Conn),
+		sessions:make(map[string]session),
 		expectedClientCounts:make(map[string]int),
 		OnClientCountChangeMap:make(map[string]func(int)),
 	}
@@ -72,9 +82,43 @@ func (hub * WebSocketHub) RemoveClient(tournamentId string,clientId string){
 	}
 }
 
+//hands out the token a client proves itself with over http while its connection lasts
+func (hub *WebSocketHub) AddSession(tournamentId string, clientId string, conn *websocket.Conn) (string,error){
+	token,err := util.GenerateShortID(32)
+	if err != nil {
+		return "",err
+	}
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.sessions[token] = session{tournamentId,clientId,conn}
+	return token,nil
+}
+
+func (hub *WebSocketHub) RemoveSessions(conn *websocket.Conn){
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for token,s := range hub.sessions {
+		if s.conn == conn {
+			delete(hub.sessions,token)
+		}
+	}
+}
+
+//the client a token was given to, as long as it is still connected to the tournament
+func (hub *WebSocketHub) Client(tournamentId string, token string) (string,bool){
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	s,ok := hub.sessions[token]
+	if !ok || s.tournamentId != tournamentId || hub.clients[tournamentId][s.clientId] != s.conn {
+		return "",false
+	}
+	return s.clientId,true
+}
+
 func (h *Handler) handleConnection(tournamentId, clientId string, conn *websocket.Conn) {
 	defer func() {
 		fmt.Printf("Cleaning up client %s from tournament %s\n", clientId, tournamentId)
+		h.WebSocketHub.RemoveSessions(conn)
 		h.WebSocketHub.RemoveClient(tournamentId, clientId)
 		conn.Close() // only called here
 	}()
@@ -144,6 +188,30 @@ func (hub *WebSocketHub) Broadcast(tournamentId string, msg interface{}){
 	}
 }
 
+//sends to the named clients only, pairs connect with their pair id as the client id
+func (hub *WebSocketHub) Send(tournamentId string, clientIds []string, msg interface{}){
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	clients, ok := hub.clients[tournamentId]
+	if !ok {
+		return
+	}
+
+	for _,clientId := range clientIds {
+		conn,ok := clients[clientId]
+		if !ok {
+			continue
+		}
+		err := conn.WriteJSON(msg)
+		if err != nil {
+			fmt.Println("Send error to client", clientId, ":", err)
+			conn.Close()
+			delete(clients,clientId)
+		}
+	}
+}
+
 func (h *Handler) WsHandler(w http.ResponseWriter,r *http.Request){
 	tournamentId := r.URL.Query().Get("tournamentId")
 	clientId := r.URL.Query().Get("clientId")
@@ -151,6 +219,12 @@ func (h *Handler) WsHandler(w http.ResponseWriter,r *http.Request){
 		http.Error(w, "Missing tournamentId/clientId",http.StatusBadRequest)
 		return
 	}
+	//only a registered pair holding its secret connects, the session token it is given stands for that pair
+	pair,err := h.Store.GetPair(r.Context(),tournamentId,clientId)
+	if err != nil || !pairSecretMatches(pair,r.URL.Query().Get("secret")) {
+		http.Error(w,"Unknown pair or wrong secret",http.StatusUnauthorized)
+		return
+	}
 	fmt.Println("ws connection request detected for tournament",tournamentId,clientId)
 	conn,err := upgrader.Upgrade(w,r,nil)
 	if err != nil {
@@ -163,6 +237,24 @@ func (h *Handler) WsHandler(w http.ResponseWriter,r *http.Request){
 	}
 	
 
+	//sent before the client joins any broadcast, so nothing else is writing to the connection yet
+	token,err := h.WebSocketHub.AddSession(tournamentId,clientId,conn)
+	if err != nil {
+		fmt.Println("Unable to start session:", err)
+		conn.Close()
+		return
+	}
+	err = conn.WriteJSON(map[string]interface{}{
+		"Type": "Session",
+		"Token": token,
+	})
+	if err != nil {
+		fmt.Println("Unable to send session token:", err)
+		h.WebSocketHub.RemoveSessions(conn)
+		conn.Close()
+		return
+	}
+
 	h.WebSocketHub.AddClient(tournamentId,clientId,conn)
 	go h.handleConnection(tournamentId, clientId, conn)
 
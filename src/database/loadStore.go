@@ -0,0 +1,35 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// LoadStore picks the backend from STORE. The default, sqlite, keeps
+// tournament history in SQLITE_PATH across restarts; memory loses everything
+// when the server stops.
+func LoadStore() Store {
+	godotenv.Load()
+
+	switch os.Getenv("STORE") {
+		case "memory":
+			fmt.Println("Using in-memory store")
+			return NewMemoryStore()
+		case "redis":
+			return NewRedisStore(LoadRedis())
+		default:
+			path := os.Getenv("SQLITE_PATH")
+			if path == "" {
+				path = "tournaments.db"
+			}
+			store, err := NewSQLStore(path)
+			if err != nil {
+				log.Fatalf("Failed to open SQLite store: %v", err)
+			}
+			fmt.Println("Using SQLite store at", path)
+			return store
+	}
+}
@@ -0,0 +1,388 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"src/types"
+	"strings"
+	"sync"
+)
+
+// MemoryStore keeps everything in maps under the same keys the Redis store
+// uses. Nothing survives a restart.
+type MemoryStore struct {
+	mu            sync.Mutex
+	tournaments   map[string]types.Tournament
+	pairs         map[string]types.Pair
+	teams         map[string]types.Team
+	boardStates   map[string]types.BoardState
+	boardResults  map[string]types.BoardResult
+	pending       map[string]types.PendingResult
+	pairResults   map[string]map[string]types.PairResultByBoard
+	leaderboards  map[string][]types.LeaderboardEntry
+	audit         map[string][]types.AuditEntry
+	events        map[string][]types.Event
+	deals         map[string]types.Deal
+	counters      map[string]int
+	finishedPairs map[string]map[string]bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tournaments:   make(map[string]types.Tournament),
+		pairs:         make(map[string]types.Pair),
+		teams:         make(map[string]types.Team),
+		boardStates:   make(map[string]types.BoardState),
+		boardResults:  make(map[string]types.BoardResult),
+		pending:       make(map[string]types.PendingResult),
+		pairResults:   make(map[string]map[string]types.PairResultByBoard),
+		leaderboards:  make(map[string][]types.LeaderboardEntry),
+		audit:         make(map[string][]types.AuditEntry),
+		events:        make(map[string][]types.Event),
+		deals:         make(map[string]types.Deal),
+		counters:      make(map[string]int),
+		finishedPairs: make(map[string]map[string]bool),
+	}
+}
+
+func (s *MemoryStore) GetTournament(ctx context.Context, tournamentId string) (*types.Tournament, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tournaments[tournamentKey(tournamentId)]
+	if !ok {
+		return nil, fmt.Errorf("failed to fetch tournament: %s %w", tournamentId, ErrNotFound)
+	}
+	t.ArrowSwitchRounds = append([]int(nil), t.ArrowSwitchRounds...)
+	return &t, nil
+}
+
+func (s *MemoryStore) SetTournament(ctx context.Context, t types.Tournament) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.ArrowSwitchRounds = append([]int(nil), t.ArrowSwitchRounds...)
+	s.tournaments[tournamentKey(t.Id)] = t
+	return nil
+}
+
+func (s *MemoryStore) GetPair(ctx context.Context, tournamentId string, pairId string) (*types.Pair, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pair, ok := s.pairs[pairKey(tournamentId, pairId)]
+	if !ok {
+		return nil, fmt.Errorf("error getting pair info: %s %w", pairId, ErrNotFound)
+	}
+	return &pair, nil
+}
+
+func (s *MemoryStore) SetPair(ctx context.Context, pair types.Pair) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pairs[pairKey(pair.TournamentId, pair.Id)] = pair
+	return nil
+}
+
+func (s *MemoryStore) add(key string, n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[key] += n
+	return s.counters[key]
+}
+
+func (s *MemoryStore) NextPairNumber(ctx context.Context, tournamentId string) (int, error) {
+	return s.add(pairCounterKey(tournamentId), 1), nil
+}
+
+func (s *MemoryStore) ReleasePairNumber(ctx context.Context, tournamentId string) error {
+	s.add(pairCounterKey(tournamentId), -1)
+	return nil
+}
+
+func (s *MemoryStore) GetTeam(ctx context.Context, tournamentId string, teamId string) (*types.Team, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	team, ok := s.teams[teamKey(tournamentId, teamId)]
+	if !ok {
+		return nil, fmt.Errorf("failed to fetch team: %s %w", teamId, ErrNotFound)
+	}
+	return &team, nil
+}
+
+func (s *MemoryStore) SetTeam(ctx context.Context, team types.Team) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	team.Pairs = nil
+	s.teams[teamKey(team.TournamentId, team.Id)] = team
+	return nil
+}
+
+func (s *MemoryStore) NextTeamNumber(ctx context.Context, tournamentId string) (int, error) {
+	return s.add(teamCounterKey(tournamentId), 1), nil
+}
+
+func (s *MemoryStore) ReleaseTeamNumber(ctx context.Context, tournamentId string) error {
+	s.add(teamCounterKey(tournamentId), -1)
+	return nil
+}
+
+func (s *MemoryStore) GetBoardState(ctx context.Context, tournamentId string, pairId string) (*types.BoardState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.boardStates[pairStateKey(tournamentId, pairId)]
+	if !ok {
+		return nil, fmt.Errorf("failed to fetch board state: %s %w", pairId, ErrNotFound)
+	}
+	return &state, nil
+}
+
+func (s *MemoryStore) SetBoardState(ctx context.Context, tournamentId string, pairId string, state types.BoardState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.boardStates[pairStateKey(tournamentId, pairId)] = state
+	return nil
+}
+
+func (s *MemoryStore) GetBoardResults(ctx context.Context, tournamentId string) ([]types.BoardResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := strings.TrimSuffix(boardResultPattern(tournamentId), "*")
+	var results []types.BoardResult
+	for key, res := range s.boardResults {
+		if strings.HasPrefix(key, prefix) {
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
+func (s *MemoryStore) GetBoardResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (*types.BoardResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, ok := s.boardResults[boardResultKey(tournamentId, boardNumber, nsPairId)]
+	if !ok {
+		return nil, fmt.Errorf("failed to fetch board result: board %d pair %s %w", boardNumber, nsPairId, ErrNotFound)
+	}
+	return &res, nil
+}
+
+func (s *MemoryStore) SetBoardResult(ctx context.Context, res types.BoardResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.boardResults[boardResultKey(res.TournamentId, res.BoardNumber, res.NSPairId)] = res
+	return nil
+}
+
+func (s *MemoryStore) DeleteBoardResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.boardResults, boardResultKey(tournamentId, boardNumber, nsPairId))
+	return nil
+}
+
+func (s *MemoryStore) GetPendingResults(ctx context.Context, tournamentId string) ([]types.PendingResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := strings.TrimSuffix(pendingResultPattern(tournamentId), "*")
+	var results []types.PendingResult
+	for key, pending := range s.pending {
+		if strings.HasPrefix(key, prefix) {
+			results = append(results, pending)
+		}
+	}
+	return results, nil
+}
+
+func (s *MemoryStore) GetPendingResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (*types.PendingResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending, ok := s.pending[pendingResultKey(tournamentId, boardNumber, nsPairId)]
+	if !ok {
+		return nil, fmt.Errorf("failed to fetch pending result: board %d pair %s %w", boardNumber, nsPairId, ErrNotFound)
+	}
+	return &pending, nil
+}
+
+func (s *MemoryStore) SetPendingResult(ctx context.Context, pending types.PendingResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[pendingResultKey(pending.TournamentId, pending.BoardNumber, pending.NSPairId)] = pending
+	return nil
+}
+
+func (s *MemoryStore) DeletePendingResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := pendingResultKey(tournamentId, boardNumber, nsPairId)
+	_, ok := s.pending[key]
+	delete(s.pending, key)
+	return ok, nil
+}
+
+func (s *MemoryStore) GetPairBoardResults(ctx context.Context, tournamentId string, pairId string) ([]types.PairResultByBoard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var results []types.PairResultByBoard
+	for _, result := range s.pairResults[pairBoardResultsKey(tournamentId, pairId)] {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].BoardNumber < results[j].BoardNumber
+	})
+	return results, nil
+}
+
+func (s *MemoryStore) SetPairBoardResult(ctx context.Context, tournamentId string, pairId string, result types.PairResultByBoard) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := pairBoardResultsKey(tournamentId, pairId)
+	if _, ok := s.pairResults[key]; !ok {
+		s.pairResults[key] = make(map[string]types.PairResultByBoard)
+	}
+	s.pairResults[key][boardField(result.BoardNumber)] = result
+	return nil
+}
+
+func (s *MemoryStore) DeletePairBoardResult(ctx context.Context, tournamentId string, pairId string, boardNumber int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pairResults[pairBoardResultsKey(tournamentId, pairId)], boardField(boardNumber))
+	return nil
+}
+
+func (s *MemoryStore) AddAuditEntry(ctx context.Context, entry types.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := auditKey(entry.TournamentId)
+	s.audit[key] = append(s.audit[key], entry)
+	return nil
+}
+
+func (s *MemoryStore) GetAuditLog(ctx context.Context, tournamentId string) ([]types.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]types.AuditEntry(nil), s.audit[auditKey(tournamentId)]...), nil
+}
+
+func (s *MemoryStore) GetLeaderboard(ctx context.Context, tournamentId string) ([]types.LeaderboardEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]types.LeaderboardEntry(nil), s.leaderboards[leaderboardKey(tournamentId)]...), nil
+}
+
+func (s *MemoryStore) SetLeaderboard(ctx context.Context, tournamentId string, entries []types.LeaderboardEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leaderboards[leaderboardKey(tournamentId)] = append([]types.LeaderboardEntry(nil), entries...)
+	return nil
+}
+
+func (s *MemoryStore) ListTournaments(ctx context.Context) ([]types.Tournament, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tournaments []types.Tournament
+	for _, t := range s.tournaments {
+		tournaments = append(tournaments, t)
+	}
+	sort.Slice(tournaments, func(i, j int) bool {
+		return tournaments[i].Id < tournaments[j].Id
+	})
+	return tournaments, nil
+}
+
+func (s *MemoryStore) AppendEvent(ctx context.Context, event types.Event) (types.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := eventsKey(event.TournamentId)
+	event.Seq = len(s.events[key]) + 1
+	s.events[key] = append(s.events[key], event)
+	return event, nil
+}
+
+func (s *MemoryStore) GetEvents(ctx context.Context, tournamentId string) ([]types.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]types.Event(nil), s.events[eventsKey(tournamentId)]...), nil
+}
+
+func clearPrefix[T any](m map[string]T, prefix string) {
+	for key := range m {
+		if strings.HasPrefix(key, prefix) {
+			delete(m, key)
+		}
+	}
+}
+
+func (s *MemoryStore) ResetTournament(ctx context.Context, tournamentId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := tournamentKey(tournamentId) + ":"
+	clearPrefix(s.pairs, prefix)
+	clearPrefix(s.teams, prefix)
+	clearPrefix(s.boardStates, prefix)
+	clearPrefix(s.boardResults, prefix)
+	clearPrefix(s.pending, prefix)
+	clearPrefix(s.pairResults, prefix)
+	clearPrefix(s.leaderboards, prefix)
+	clearPrefix(s.counters, prefix)
+	clearPrefix(s.finishedPairs, prefix)
+	return nil
+}
+
+func (s *MemoryStore) FinishPair(ctx context.Context, tournamentId string, pairId string) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := finishedPairsKey(tournamentId)
+	if _, ok := s.finishedPairs[key]; !ok {
+		s.finishedPairs[key] = make(map[string]bool)
+	}
+	if s.finishedPairs[key][pairId] {
+		return len(s.finishedPairs[key]), false, nil
+	}
+	s.finishedPairs[key][pairId] = true
+	return len(s.finishedPairs[key]), true, nil
+}
+
+func (s *MemoryStore) IsPairFinished(ctx context.Context, tournamentId string, pairId string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.finishedPairs[finishedPairsKey(tournamentId)][pairId], nil
+}
+
+func (s *MemoryStore) FinishedPairCount(ctx context.Context, tournamentId string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.finishedPairs[finishedPairsKey(tournamentId)]), nil
+}
+
+func (s *MemoryStore) GetDeal(ctx context.Context, tournamentId string, boardNumber int) (*types.Deal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deal, ok := s.deals[dealKey(tournamentId, boardNumber)]
+	if !ok {
+		return nil, fmt.Errorf("failed to fetch deal: board %d %w", boardNumber, ErrNotFound)
+	}
+	return &deal, nil
+}
+
+func (s *MemoryStore) GetDeals(ctx context.Context, tournamentId string) ([]types.Deal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := strings.TrimSuffix(dealPattern(tournamentId), "*")
+	var deals []types.Deal
+	for key, deal := range s.deals {
+		if strings.HasPrefix(key, prefix) {
+			deals = append(deals, deal)
+		}
+	}
+	sort.Slice(deals, func(i, j int) bool {
+		return deals[i].BoardNumber < deals[j].BoardNumber
+	})
+	return deals, nil
+}
+
+func (s *MemoryStore) SetDeal(ctx context.Context, deal types.Deal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deals[dealKey(deal.TournamentId, deal.BoardNumber)] = deal
+	return nil
+}
@@ -0,0 +1,562 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"src/types"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps every record as a hash under a tournament:<id> key.
+type RedisStore struct {
+	Redis *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{Redis: client}
+}
+
+func (s *RedisStore) getHash(ctx context.Context, key string) (map[string]string, error) {
+	data, err := s.Redis.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s %w", key, ErrNotFound)
+	}
+	return data, nil
+}
+
+func (s *RedisStore) GetTournament(ctx context.Context, tournamentId string) (*types.Tournament, error) {
+	data, err := s.getHash(ctx, tournamentKey(tournamentId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tournament: %w", err)
+	}
+
+	t := types.Tournament{Id: tournamentId}
+	fmt.Sscanf(data["BoardsPerRound"], "%d", &t.BoardsPerRound)
+	fmt.Sscanf(data["TotalRounds"], "%d", &t.TotalRounds)
+	fmt.Sscanf(data["Type"], "%d", &t.Type)
+	fmt.Sscanf(data["Teams"], "%d", &t.Teams)
+	fmt.Sscanf(data["ScoringMethod"], "%d", &t.ScoringMethod)
+	fmt.Sscanf(data["Movement"], "%d", &t.Movement)
+	fmt.Sscanf(data["AveragePlus"], "%g", &t.AveragePlus)
+	fmt.Sscanf(data["Average"], "%g", &t.Average)
+	fmt.Sscanf(data["AverageMinus"], "%g", &t.AverageMinus)
+	t.LiveStandings = data["LiveStandings"] == "1"
+	if val, ok := data["ArrowSwitchRounds"]; ok && val != "" {
+		if err := json.Unmarshal([]byte(val), &t.ArrowSwitchRounds); err != nil {
+			return nil, fmt.Errorf("invalid arrow switch rounds for tournament %s: %w", tournamentId, err)
+		}
+	}
+	return &t, nil
+}
+
+func (s *RedisStore) SetTournament(ctx context.Context, t types.Tournament) error {
+	arrowSwitchRounds, err := json.Marshal(t.ArrowSwitchRounds)
+	if err != nil {
+		return err
+	}
+	if err := s.Redis.SAdd(ctx, tournamentsKey, t.Id).Err(); err != nil {
+		return err
+	}
+	return s.Redis.HSet(ctx, tournamentKey(t.Id), map[string]interface{}{
+		"Id":                t.Id,
+		"BoardsPerRound":    t.BoardsPerRound,
+		"TotalRounds":       t.TotalRounds,
+		"Type":              t.Type,
+		"Teams":             t.Teams,
+		"ScoringMethod":     t.ScoringMethod,
+		"Movement":          t.Movement,
+		"ArrowSwitchRounds": string(arrowSwitchRounds),
+		"AveragePlus":       t.AveragePlus,
+		"Average":           t.Average,
+		"AverageMinus":      t.AverageMinus,
+		"LiveStandings":     t.LiveStandings,
+	}).Err()
+}
+
+func (s *RedisStore) GetPair(ctx context.Context, tournamentId string, pairId string) (*types.Pair, error) {
+	data, err := s.getHash(ctx, pairKey(tournamentId, pairId))
+	if err != nil {
+		return nil, fmt.Errorf("error getting pair info: %w", err)
+	}
+	return &types.Pair{
+		Id:           data["Id"],
+		Name1:        data["Name1"],
+		Name2:        data["Name2"],
+		TournamentId: data["TournamentId"],
+		TeamId:       data["TeamId"],
+		SecretHash:   data["SecretHash"],
+	}, nil
+}
+
+func (s *RedisStore) SetPair(ctx context.Context, pair types.Pair) error {
+	return s.Redis.HSet(ctx, pairKey(pair.TournamentId, pair.Id), map[string]interface{}{
+		"Id":           pair.Id,
+		"Name1":        pair.Name1,
+		"Name2":        pair.Name2,
+		"TournamentId": pair.TournamentId,
+		"TeamId":       pair.TeamId,
+		"SecretHash":   pair.SecretHash,
+	}).Err()
+}
+
+func (s *RedisStore) NextPairNumber(ctx context.Context, tournamentId string) (int, error) {
+	count, err := s.Redis.Incr(ctx, pairCounterKey(tournamentId)).Result()
+	return int(count), err
+}
+
+func (s *RedisStore) ReleasePairNumber(ctx context.Context, tournamentId string) error {
+	return s.Redis.Decr(ctx, pairCounterKey(tournamentId)).Err()
+}
+
+func (s *RedisStore) GetTeam(ctx context.Context, tournamentId string, teamId string) (*types.Team, error) {
+	data, err := s.getHash(ctx, teamKey(tournamentId, teamId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch team: %w", err)
+	}
+	return &types.Team{
+		Id:           data["Id"],
+		Name:         data["Name"],
+		TournamentId: data["TournamentId"],
+	}, nil
+}
+
+func (s *RedisStore) SetTeam(ctx context.Context, team types.Team) error {
+	return s.Redis.HSet(ctx, teamKey(team.TournamentId, team.Id), map[string]interface{}{
+		"Id":           team.Id,
+		"Name":         team.Name,
+		"TournamentId": team.TournamentId,
+	}).Err()
+}
+
+func (s *RedisStore) NextTeamNumber(ctx context.Context, tournamentId string) (int, error) {
+	count, err := s.Redis.Incr(ctx, teamCounterKey(tournamentId)).Result()
+	return int(count), err
+}
+
+func (s *RedisStore) ReleaseTeamNumber(ctx context.Context, tournamentId string) error {
+	return s.Redis.Decr(ctx, teamCounterKey(tournamentId)).Err()
+}
+
+func (s *RedisStore) GetBoardState(ctx context.Context, tournamentId string, pairId string) (*types.BoardState, error) {
+	data, err := s.getHash(ctx, pairStateKey(tournamentId, pairId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch board state: %w", err)
+	}
+
+	var state types.BoardState
+	fmt.Sscanf(data["CurrentBoard"], "%d", &state.CurrentBoard)
+	fmt.Sscanf(data["CurrentRound"], "%d", &state.CurrentRound)
+	state.CurrentOpp = data["CurrentOpp"]
+	state.Direction = data["Direction"]
+	state.Room = data["Room"]
+	state.IsSitOut = data["IsSitOut"] == "1"
+	return &state, nil
+}
+
+func (s *RedisStore) SetBoardState(ctx context.Context, tournamentId string, pairId string, state types.BoardState) error {
+	return s.Redis.HSet(ctx, pairStateKey(tournamentId, pairId), map[string]interface{}{
+		"CurrentBoard": state.CurrentBoard,
+		"CurrentOpp":   state.CurrentOpp,
+		"CurrentRound": state.CurrentRound,
+		"Direction":    state.Direction,
+		"Room":         state.Room,
+		"IsSitOut":     state.IsSitOut,
+	}).Err()
+}
+
+func (s *RedisStore) GetBoardResults(ctx context.Context, tournamentId string) ([]types.BoardResult, error) {
+	var results []types.BoardResult
+	iter := s.Redis.Scan(ctx, 0, boardResultPattern(tournamentId), 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		data, err := s.Redis.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch board result: %w", err)
+		}
+		br, err := parseBoardResult(key, data)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *br)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
+	}
+	return results, nil
+}
+
+func (s *RedisStore) GetBoardResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (*types.BoardResult, error) {
+	key := boardResultKey(tournamentId, boardNumber, nsPairId)
+	data, err := s.getHash(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch board result: %w", err)
+	}
+	return parseBoardResult(key, data)
+}
+
+func parseBoardResult(key string, data map[string]string) (*types.BoardResult, error) {
+	br := types.BoardResult{
+		Contract:     data["Contract"],
+		Direction:    data["Direction"],
+		DeclarerSeat: data["DeclarerSeat"],
+		Result:       data["Result"],
+		NSPairId:     data["NSPairId"],
+		EWPairId:     data["EWPairId"],
+		TournamentId: data["TournamentId"],
+		Vul:          data["Vul"],
+		Adjustment:   data["Adjustment"],
+		NSArtificial: data["NSArtificial"],
+		EWArtificial: data["EWArtificial"],
+	}
+	br.BoardNumber, _ = strconv.Atoi(data["BoardNumber"])
+	for field, dest := range map[string]*int{"Score": &br.Score, "EWScore": &br.EWScore, "FoulGroup": &br.FoulGroup} {
+		s, ok := data[field]
+		if !ok {
+			continue
+		}
+		val, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value for key %s: %w", field, key, err)
+		}
+		*dest = val
+	}
+	return &br, nil
+}
+
+func (s *RedisStore) SetBoardResult(ctx context.Context, res types.BoardResult) error {
+	return s.Redis.HSet(ctx, boardResultKey(res.TournamentId, res.BoardNumber, res.NSPairId), map[string]interface{}{
+		"BoardNumber":  res.BoardNumber,
+		"Vul":          res.Vul,
+		"Contract":     res.Contract,
+		"Direction":    res.Direction,
+		"DeclarerSeat": res.DeclarerSeat,
+		"Result":       res.Result,
+		"NSPairId":     res.NSPairId,
+		"EWPairId":     res.EWPairId,
+		"TournamentId": res.TournamentId,
+		"Score":        res.Score,
+		"Adjustment":   res.Adjustment,
+		"NSArtificial": res.NSArtificial,
+		"EWArtificial": res.EWArtificial,
+		"EWScore":      res.EWScore,
+		"FoulGroup":    res.FoulGroup,
+	}).Err()
+}
+
+func (s *RedisStore) DeleteBoardResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) error {
+	return s.Redis.Del(ctx, boardResultKey(tournamentId, boardNumber, nsPairId)).Err()
+}
+
+func (s *RedisStore) GetPendingResults(ctx context.Context, tournamentId string) ([]types.PendingResult, error) {
+	var results []types.PendingResult
+	iter := s.Redis.Scan(ctx, 0, pendingResultPattern(tournamentId), 0).Iterator()
+	for iter.Next(ctx) {
+		data, err := s.Redis.Get(ctx, iter.Val()).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pending result: %w", err)
+		}
+		var pending types.PendingResult
+		if err := json.Unmarshal([]byte(data), &pending); err != nil {
+			return nil, fmt.Errorf("invalid pending result %s: %w", iter.Val(), err)
+		}
+		results = append(results, pending)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
+	}
+	return results, nil
+}
+
+func (s *RedisStore) GetPendingResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (*types.PendingResult, error) {
+	key := pendingResultKey(tournamentId, boardNumber, nsPairId)
+	data, err := s.Redis.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("failed to fetch pending result: %s %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending result: %w", err)
+	}
+	var pending types.PendingResult
+	if err := json.Unmarshal([]byte(data), &pending); err != nil {
+		return nil, fmt.Errorf("invalid pending result %s: %w", key, err)
+	}
+	return &pending, nil
+}
+
+func (s *RedisStore) SetPendingResult(ctx context.Context, pending types.PendingResult) error {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	return s.Redis.Set(ctx, pendingResultKey(pending.TournamentId, pending.BoardNumber, pending.NSPairId), string(data), 0).Err()
+}
+
+func (s *RedisStore) DeletePendingResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (bool, error) {
+	deleted, err := s.Redis.Del(ctx, pendingResultKey(tournamentId, boardNumber, nsPairId)).Result()
+	return deleted > 0, err
+}
+
+func (s *RedisStore) GetPairBoardResults(ctx context.Context, tournamentId string, pairId string) ([]types.PairResultByBoard, error) {
+	data, err := s.Redis.HGetAll(ctx, pairBoardResultsKey(tournamentId, pairId)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch board results: %w", err)
+	}
+
+	var results []types.PairResultByBoard
+	for field, value := range data {
+		var result types.PairResultByBoard
+		if err := json.Unmarshal([]byte(value), &result); err != nil {
+			fmt.Printf("Failed to unmarshal board %s: %v\n", field, err)
+			continue
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].BoardNumber < results[j].BoardNumber
+	})
+	return results, nil
+}
+
+func (s *RedisStore) SetPairBoardResult(ctx context.Context, tournamentId string, pairId string, result types.PairResultByBoard) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return s.Redis.HSet(ctx, pairBoardResultsKey(tournamentId, pairId), boardField(result.BoardNumber), string(data)).Err()
+}
+
+func (s *RedisStore) DeletePairBoardResult(ctx context.Context, tournamentId string, pairId string, boardNumber int) error {
+	return s.Redis.HDel(ctx, pairBoardResultsKey(tournamentId, pairId), boardField(boardNumber)).Err()
+}
+
+func (s *RedisStore) AddAuditEntry(ctx context.Context, entry types.AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.Redis.RPush(ctx, auditKey(entry.TournamentId), string(data)).Err()
+}
+
+func (s *RedisStore) GetAuditLog(ctx context.Context, tournamentId string) ([]types.AuditEntry, error) {
+	data, err := s.Redis.LRange(ctx, auditKey(tournamentId), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch audit log: %w", err)
+	}
+	var entries []types.AuditEntry
+	for _, value := range data {
+		var entry types.AuditEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			return nil, fmt.Errorf("invalid audit entry for tournament %s: %w", tournamentId, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (s *RedisStore) GetLeaderboard(ctx context.Context, tournamentId string) ([]types.LeaderboardEntry, error) {
+	data, err := s.Redis.Get(ctx, leaderboardKey(tournamentId)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch leaderboard: %w", err)
+	}
+	var entries []types.LeaderboardEntry
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return nil, fmt.Errorf("invalid leaderboard for tournament %s: %w", tournamentId, err)
+	}
+	return entries, nil
+}
+
+func (s *RedisStore) SetLeaderboard(ctx context.Context, tournamentId string, entries []types.LeaderboardEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return s.Redis.Set(ctx, leaderboardKey(tournamentId), string(data), 0).Err()
+}
+
+func (s *RedisStore) ListTournaments(ctx context.Context) ([]types.Tournament, error) {
+	ids, err := s.Redis.SMembers(ctx, tournamentsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tournaments: %w", err)
+	}
+	sort.Strings(ids)
+	var tournaments []types.Tournament
+	for _, id := range ids {
+		t, err := s.GetTournament(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		tournaments = append(tournaments, *t)
+	}
+	return tournaments, nil
+}
+
+func (s *RedisStore) AppendEvent(ctx context.Context, event types.Event) (types.Event, error) {
+	seq, err := s.Redis.Incr(ctx, eventCounterKey(event.TournamentId)).Result()
+	if err != nil {
+		return event, err
+	}
+	event.Seq = int(seq)
+	data, err := json.Marshal(event)
+	if err != nil {
+		return event, err
+	}
+	return event, s.Redis.RPush(ctx, eventsKey(event.TournamentId), string(data)).Err()
+}
+
+func (s *RedisStore) GetEvents(ctx context.Context, tournamentId string) ([]types.Event, error) {
+	data, err := s.Redis.LRange(ctx, eventsKey(tournamentId), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch events: %w", err)
+	}
+	var events []types.Event
+	for _, value := range data {
+		var event types.Event
+		if err := json.Unmarshal([]byte(value), &event); err != nil {
+			return nil, fmt.Errorf("invalid event for tournament %s: %w", tournamentId, err)
+		}
+		events = append(events, event)
+	}
+	//two commands at once can push out of order
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Seq < events[j].Seq
+	})
+	return events, nil
+}
+
+func (s *RedisStore) ResetTournament(ctx context.Context, tournamentId string) error {
+	keep := map[string]bool{
+		auditKey(tournamentId):        true,
+		eventsKey(tournamentId):       true,
+		eventCounterKey(tournamentId): true,
+	}
+	deals := strings.TrimSuffix(dealPattern(tournamentId), "*")
+	var keys []string
+	iter := s.Redis.Scan(ctx, 0, tournamentKey(tournamentId)+":*", 0).Iterator()
+	for iter.Next(ctx) {
+		if !keep[iter.Val()] && !strings.HasPrefix(iter.Val(), deals) {
+			keys = append(keys, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("iteration error: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.Redis.Del(ctx, keys...).Err()
+}
+
+func (s *RedisStore) FinishPair(ctx context.Context, tournamentId string, pairId string) (int, bool, error) {
+	added, err := s.Redis.SAdd(ctx, finishedPairsKey(tournamentId), pairId).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	if added == 0 {
+		count, err := s.FinishedPairCount(ctx, tournamentId)
+		return count, false, err
+	}
+	count, err := s.Redis.Incr(ctx, finishedCounterKey(tournamentId)).Result()
+	return int(count), true, err
+}
+
+func (s *RedisStore) IsPairFinished(ctx context.Context, tournamentId string, pairId string) (bool, error) {
+	return s.Redis.SIsMember(ctx, finishedPairsKey(tournamentId), pairId).Result()
+}
+
+func (s *RedisStore) FinishedPairCount(ctx context.Context, tournamentId string) (int, error) {
+	count, err := s.Redis.Get(ctx, finishedCounterKey(tournamentId)).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+func parseDeal(tournamentId string, data map[string]string) types.Deal {
+	deal := types.Deal{
+		TournamentId:  tournamentId,
+		Dealer:        data["Dealer"],
+		Vulnerability: data["Vulnerability"],
+		North:         data["North"],
+		East:          data["East"],
+		South:         data["South"],
+		West:          data["West"],
+	}
+	fmt.Sscanf(data["BoardNumber"], "%d", &deal.BoardNumber)
+	if data["Analysis"] != "" {
+		var analysis types.Analysis
+		if err := json.Unmarshal([]byte(data["Analysis"]), &analysis); err != nil {
+			fmt.Printf("Failed to unmarshal analysis of board %d: %v\n", deal.BoardNumber, err)
+		} else {
+			deal.Analysis = &analysis
+		}
+	}
+	return deal
+}
+
+func (s *RedisStore) GetDeal(ctx context.Context, tournamentId string, boardNumber int) (*types.Deal, error) {
+	data, err := s.getHash(ctx, dealKey(tournamentId, boardNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deal: %w", err)
+	}
+	deal := parseDeal(tournamentId, data)
+	return &deal, nil
+}
+
+func (s *RedisStore) GetDeals(ctx context.Context, tournamentId string) ([]types.Deal, error) {
+	var deals []types.Deal
+	iter := s.Redis.Scan(ctx, 0, dealPattern(tournamentId), 0).Iterator()
+	for iter.Next(ctx) {
+		data, err := s.Redis.HGetAll(ctx, iter.Val()).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch deal: %w", err)
+		}
+		deals = append(deals, parseDeal(tournamentId, data))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
+	}
+	sort.Slice(deals, func(i, j int) bool {
+		return deals[i].BoardNumber < deals[j].BoardNumber
+	})
+	return deals, nil
+}
+
+func (s *RedisStore) SetDeal(ctx context.Context, deal types.Deal) error {
+	key := dealKey(deal.TournamentId, deal.BoardNumber)
+	fields := map[string]interface{}{
+		"BoardNumber":   deal.BoardNumber,
+		"Dealer":        deal.Dealer,
+		"Vulnerability": deal.Vulnerability,
+		"North":         deal.North,
+		"East":          deal.East,
+		"South":         deal.South,
+		"West":          deal.West,
+	}
+	//new hands drop the analysis of the old ones
+	if deal.Analysis == nil {
+		if err := s.Redis.HDel(ctx, key, "Analysis").Err(); err != nil {
+			return err
+		}
+	} else {
+		data, err := json.Marshal(deal.Analysis)
+		if err != nil {
+			return err
+		}
+		fields["Analysis"] = string(data)
+	}
+	return s.Redis.HSet(ctx, key, fields).Err()
+}
@@ -0,0 +1,766 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"src/types"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Schema changes are appended here and never edited once released. Each one
+// runs once, in order, and its index is recorded in schema_migrations.
+var migrations = []string{
+	`CREATE TABLE tournaments (
+		id TEXT PRIMARY KEY,
+		boards_per_round INTEGER NOT NULL,
+		total_rounds INTEGER NOT NULL,
+		type INTEGER NOT NULL,
+		teams INTEGER NOT NULL,
+		scoring_method INTEGER NOT NULL,
+		movement INTEGER NOT NULL,
+		arrow_switch_rounds TEXT NOT NULL DEFAULT '[]',
+		average_plus REAL NOT NULL DEFAULT 0,
+		average REAL NOT NULL DEFAULT 0,
+		average_minus REAL NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE pairs (
+		tournament_id TEXT NOT NULL REFERENCES tournaments(id),
+		id TEXT NOT NULL,
+		name1 TEXT NOT NULL,
+		name2 TEXT NOT NULL,
+		team_id TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (tournament_id, id)
+	);
+	CREATE TABLE teams (
+		tournament_id TEXT NOT NULL REFERENCES tournaments(id),
+		id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		PRIMARY KEY (tournament_id, id)
+	);
+	CREATE TABLE counters (
+		tournament_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		value INTEGER NOT NULL,
+		PRIMARY KEY (tournament_id, name)
+	);
+	CREATE TABLE board_states (
+		tournament_id TEXT NOT NULL,
+		pair_id TEXT NOT NULL,
+		current_board INTEGER NOT NULL,
+		current_opp TEXT NOT NULL,
+		current_round INTEGER NOT NULL,
+		direction TEXT NOT NULL,
+		room TEXT NOT NULL,
+		is_sit_out INTEGER NOT NULL,
+		PRIMARY KEY (tournament_id, pair_id)
+	);
+	CREATE TABLE board_results (
+		tournament_id TEXT NOT NULL REFERENCES tournaments(id),
+		board_number INTEGER NOT NULL,
+		ns_pair_id TEXT NOT NULL,
+		ew_pair_id TEXT NOT NULL,
+		vul TEXT NOT NULL,
+		contract TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		result TEXT NOT NULL,
+		score INTEGER NOT NULL,
+		adjustment TEXT NOT NULL,
+		ns_artificial TEXT NOT NULL,
+		ew_artificial TEXT NOT NULL,
+		ew_score INTEGER NOT NULL,
+		foul_group INTEGER NOT NULL,
+		PRIMARY KEY (tournament_id, board_number, ns_pair_id)
+	);
+	CREATE TABLE pair_board_results (
+		tournament_id TEXT NOT NULL,
+		pair_id TEXT NOT NULL,
+		board_number INTEGER NOT NULL,
+		contract TEXT NOT NULL,
+		result TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		raw_score INTEGER NOT NULL,
+		percentage REAL NOT NULL,
+		imps REAL NOT NULL,
+		datum INTEGER NOT NULL,
+		PRIMARY KEY (tournament_id, pair_id, board_number)
+	);
+	CREATE TABLE finished_pairs (
+		tournament_id TEXT NOT NULL,
+		pair_id TEXT NOT NULL,
+		PRIMARY KEY (tournament_id, pair_id)
+	);
+	CREATE TABLE leaderboards (
+		tournament_id TEXT NOT NULL REFERENCES tournaments(id),
+		section TEXT NOT NULL,
+		rank INTEGER NOT NULL,
+		pair_id TEXT NOT NULL,
+		name1 TEXT NOT NULL,
+		name2 TEXT NOT NULL,
+		mp_score REAL NOT NULL,
+		percentage REAL NOT NULL,
+		imps REAL NOT NULL,
+		vps REAL NOT NULL,
+		PRIMARY KEY (tournament_id, section, pair_id)
+	);`,
+	`CREATE TABLE pending_results (
+		tournament_id TEXT NOT NULL REFERENCES tournaments(id),
+		board_number INTEGER NOT NULL,
+		ns_pair_id TEXT NOT NULL,
+		ew_pair_id TEXT NOT NULL,
+		vul TEXT NOT NULL,
+		contract TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		result TEXT NOT NULL,
+		score INTEGER NOT NULL,
+		adjustment TEXT NOT NULL,
+		ns_artificial TEXT NOT NULL,
+		ew_artificial TEXT NOT NULL,
+		ew_score INTEGER NOT NULL,
+		foul_group INTEGER NOT NULL,
+		submitted_by TEXT NOT NULL,
+		status TEXT NOT NULL,
+		dispute_reason TEXT NOT NULL,
+		PRIMARY KEY (tournament_id, board_number, ns_pair_id)
+	);`,
+	`CREATE TABLE audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tournament_id TEXT NOT NULL REFERENCES tournaments(id),
+		time TEXT NOT NULL,
+		director TEXT NOT NULL,
+		action TEXT NOT NULL,
+		board_number INTEGER NOT NULL,
+		ns_pair_id TEXT NOT NULL,
+		before TEXT NOT NULL,
+		after TEXT NOT NULL,
+		reason TEXT NOT NULL
+	);`,
+	`CREATE TABLE events (
+		tournament_id TEXT NOT NULL REFERENCES tournaments(id),
+		seq INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		time TEXT NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (tournament_id, seq)
+	);`,
+	`ALTER TABLE tournaments ADD COLUMN live_standings INTEGER NOT NULL DEFAULT 0;`,
+	`CREATE TABLE deals (
+		tournament_id TEXT NOT NULL REFERENCES tournaments(id),
+		board_number INTEGER NOT NULL,
+		dealer TEXT NOT NULL,
+		vulnerability TEXT NOT NULL,
+		north TEXT NOT NULL,
+		east TEXT NOT NULL,
+		south TEXT NOT NULL,
+		west TEXT NOT NULL,
+		PRIMARY KEY (tournament_id, board_number)
+	);`,
+	`ALTER TABLE deals ADD COLUMN analysis TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE pair_board_results ADD COLUMN par_score INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE pair_board_results ADD COLUMN par_contract TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE pairs ADD COLUMN secret_hash TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE board_results ADD COLUMN declarer_seat TEXT NOT NULL DEFAULT '';
+	ALTER TABLE pending_results ADD COLUMN declarer_seat TEXT NOT NULL DEFAULT '';`,
+}
+
+// SQLStore keeps tournaments in an embedded SQLite database so they outlive
+// the server.
+type SQLStore struct {
+	DB *sql.DB
+}
+
+func NewSQLStore(path string) (*SQLStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	//SQLite allows one writer, queue everything on one connection
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLStore{DB: db}, nil
+}
+
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	err = db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		fmt.Println("Applied migration", version)
+	}
+	return nil
+}
+
+func notFound(err error, what string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s %w", what, ErrNotFound)
+	}
+	return err
+}
+
+func (s *SQLStore) GetTournament(ctx context.Context, tournamentId string) (*types.Tournament, error) {
+	t := types.Tournament{Id: tournamentId}
+	var arrowSwitchRounds string
+	err := s.DB.QueryRowContext(ctx, `SELECT boards_per_round, total_rounds, type, teams, scoring_method, movement,
+		arrow_switch_rounds, average_plus, average, average_minus, live_standings FROM tournaments WHERE id = ?`, tournamentId).Scan(
+		&t.BoardsPerRound, &t.TotalRounds, &t.Type, &t.Teams, &t.ScoringMethod, &t.Movement,
+		&arrowSwitchRounds, &t.AveragePlus, &t.Average, &t.AverageMinus, &t.LiveStandings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tournament: %w", notFound(err, tournamentId))
+	}
+	if err := json.Unmarshal([]byte(arrowSwitchRounds), &t.ArrowSwitchRounds); err != nil {
+		return nil, fmt.Errorf("invalid arrow switch rounds for tournament %s: %w", tournamentId, err)
+	}
+	return &t, nil
+}
+
+func (s *SQLStore) SetTournament(ctx context.Context, t types.Tournament) error {
+	arrowSwitchRounds, err := json.Marshal(t.ArrowSwitchRounds)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx, `INSERT INTO tournaments (id, boards_per_round, total_rounds, type, teams,
+		scoring_method, movement, arrow_switch_rounds, average_plus, average, average_minus, live_standings)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET boards_per_round = excluded.boards_per_round, total_rounds = excluded.total_rounds,
+		type = excluded.type, teams = excluded.teams, scoring_method = excluded.scoring_method, movement = excluded.movement,
+		arrow_switch_rounds = excluded.arrow_switch_rounds, average_plus = excluded.average_plus,
+		average = excluded.average, average_minus = excluded.average_minus, live_standings = excluded.live_standings`,
+		t.Id, t.BoardsPerRound, t.TotalRounds, t.Type, t.Teams, t.ScoringMethod, t.Movement,
+		string(arrowSwitchRounds), t.AveragePlus, t.Average, t.AverageMinus, t.LiveStandings)
+	return err
+}
+
+func (s *SQLStore) GetPair(ctx context.Context, tournamentId string, pairId string) (*types.Pair, error) {
+	pair := types.Pair{Id: pairId, TournamentId: tournamentId}
+	err := s.DB.QueryRowContext(ctx, "SELECT name1, name2, team_id, secret_hash FROM pairs WHERE tournament_id = ? AND id = ?",
+		tournamentId, pairId).Scan(&pair.Name1, &pair.Name2, &pair.TeamId, &pair.SecretHash)
+	if err != nil {
+		return nil, fmt.Errorf("error getting pair info: %w", notFound(err, pairId))
+	}
+	return &pair, nil
+}
+
+func (s *SQLStore) SetPair(ctx context.Context, pair types.Pair) error {
+	_, err := s.DB.ExecContext(ctx, "INSERT OR REPLACE INTO pairs (tournament_id, id, name1, name2, team_id, secret_hash) VALUES (?, ?, ?, ?, ?, ?)",
+		pair.TournamentId, pair.Id, pair.Name1, pair.Name2, pair.TeamId, pair.SecretHash)
+	return err
+}
+
+func (s *SQLStore) addCounter(ctx context.Context, tournamentId string, name string, n int) (int, error) {
+	var value int
+	err := s.DB.QueryRowContext(ctx, `INSERT INTO counters (tournament_id, name, value) VALUES (?, ?, ?)
+		ON CONFLICT (tournament_id, name) DO UPDATE SET value = value + excluded.value RETURNING value`,
+		tournamentId, name, n).Scan(&value)
+	return value, err
+}
+
+func (s *SQLStore) NextPairNumber(ctx context.Context, tournamentId string) (int, error) {
+	return s.addCounter(ctx, tournamentId, "pair_counter", 1)
+}
+
+func (s *SQLStore) ReleasePairNumber(ctx context.Context, tournamentId string) error {
+	_, err := s.addCounter(ctx, tournamentId, "pair_counter", -1)
+	return err
+}
+
+func (s *SQLStore) GetTeam(ctx context.Context, tournamentId string, teamId string) (*types.Team, error) {
+	team := types.Team{Id: teamId, TournamentId: tournamentId}
+	err := s.DB.QueryRowContext(ctx, "SELECT name FROM teams WHERE tournament_id = ? AND id = ?",
+		tournamentId, teamId).Scan(&team.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch team: %w", notFound(err, teamId))
+	}
+	return &team, nil
+}
+
+func (s *SQLStore) SetTeam(ctx context.Context, team types.Team) error {
+	_, err := s.DB.ExecContext(ctx, "INSERT OR REPLACE INTO teams (tournament_id, id, name) VALUES (?, ?, ?)",
+		team.TournamentId, team.Id, team.Name)
+	return err
+}
+
+func (s *SQLStore) NextTeamNumber(ctx context.Context, tournamentId string) (int, error) {
+	return s.addCounter(ctx, tournamentId, "team_counter", 1)
+}
+
+func (s *SQLStore) ReleaseTeamNumber(ctx context.Context, tournamentId string) error {
+	_, err := s.addCounter(ctx, tournamentId, "team_counter", -1)
+	return err
+}
+
+func (s *SQLStore) GetBoardState(ctx context.Context, tournamentId string, pairId string) (*types.BoardState, error) {
+	var state types.BoardState
+	err := s.DB.QueryRowContext(ctx, `SELECT current_board, current_opp, current_round, direction, room, is_sit_out
+		FROM board_states WHERE tournament_id = ? AND pair_id = ?`, tournamentId, pairId).Scan(
+		&state.CurrentBoard, &state.CurrentOpp, &state.CurrentRound, &state.Direction, &state.Room, &state.IsSitOut)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch board state: %w", notFound(err, pairId))
+	}
+	return &state, nil
+}
+
+func (s *SQLStore) SetBoardState(ctx context.Context, tournamentId string, pairId string, state types.BoardState) error {
+	_, err := s.DB.ExecContext(ctx, `INSERT OR REPLACE INTO board_states (tournament_id, pair_id, current_board,
+		current_opp, current_round, direction, room, is_sit_out) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		tournamentId, pairId, state.CurrentBoard, state.CurrentOpp, state.CurrentRound, state.Direction, state.Room, state.IsSitOut)
+	return err
+}
+
+const boardResultColumns = `board_number, vul, contract, direction, result, ns_pair_id, ew_pair_id, tournament_id,
+	score, adjustment, ns_artificial, ew_artificial, ew_score, foul_group, declarer_seat`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBoardResult(row scanner) (*types.BoardResult, error) {
+	var br types.BoardResult
+	err := row.Scan(&br.BoardNumber, &br.Vul, &br.Contract, &br.Direction, &br.Result, &br.NSPairId, &br.EWPairId,
+		&br.TournamentId, &br.Score, &br.Adjustment, &br.NSArtificial, &br.EWArtificial, &br.EWScore, &br.FoulGroup, &br.DeclarerSeat)
+	return &br, err
+}
+
+func (s *SQLStore) GetBoardResults(ctx context.Context, tournamentId string) ([]types.BoardResult, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT "+boardResultColumns+" FROM board_results WHERE tournament_id = ?", tournamentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch board results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []types.BoardResult
+	for rows.Next() {
+		br, err := scanBoardResult(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read board result: %w", err)
+		}
+		results = append(results, *br)
+	}
+	return results, rows.Err()
+}
+
+func (s *SQLStore) GetBoardResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (*types.BoardResult, error) {
+	row := s.DB.QueryRowContext(ctx, "SELECT "+boardResultColumns+` FROM board_results
+		WHERE tournament_id = ? AND board_number = ? AND ns_pair_id = ?`, tournamentId, boardNumber, nsPairId)
+	br, err := scanBoardResult(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch board result: %w", notFound(err, fmt.Sprintf("board %d pair %s", boardNumber, nsPairId)))
+	}
+	return br, nil
+}
+
+func (s *SQLStore) SetBoardResult(ctx context.Context, res types.BoardResult) error {
+	_, err := s.DB.ExecContext(ctx, "INSERT OR REPLACE INTO board_results ("+boardResultColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		res.BoardNumber, res.Vul, res.Contract, res.Direction, res.Result, res.NSPairId, res.EWPairId,
+		res.TournamentId, res.Score, res.Adjustment, res.NSArtificial, res.EWArtificial, res.EWScore, res.FoulGroup,
+		res.DeclarerSeat)
+	return err
+}
+
+const pendingResultColumns = boardResultColumns + ", submitted_by, status, dispute_reason"
+
+func scanPendingResult(row scanner) (*types.PendingResult, error) {
+	var p types.PendingResult
+	err := row.Scan(&p.BoardNumber, &p.Vul, &p.Contract, &p.Direction, &p.Result, &p.NSPairId, &p.EWPairId,
+		&p.TournamentId, &p.Score, &p.Adjustment, &p.NSArtificial, &p.EWArtificial, &p.EWScore, &p.FoulGroup,
+		&p.DeclarerSeat, &p.SubmittedBy, &p.Status, &p.DisputeReason)
+	return &p, err
+}
+
+func (s *SQLStore) DeleteBoardResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) error {
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM board_results WHERE tournament_id = ? AND board_number = ? AND ns_pair_id = ?",
+		tournamentId, boardNumber, nsPairId)
+	return err
+}
+
+func (s *SQLStore) GetPendingResults(ctx context.Context, tournamentId string) ([]types.PendingResult, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT "+pendingResultColumns+" FROM pending_results WHERE tournament_id = ?", tournamentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []types.PendingResult
+	for rows.Next() {
+		p, err := scanPendingResult(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pending result: %w", err)
+		}
+		results = append(results, *p)
+	}
+	return results, rows.Err()
+}
+
+func (s *SQLStore) GetPendingResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (*types.PendingResult, error) {
+	row := s.DB.QueryRowContext(ctx, "SELECT "+pendingResultColumns+` FROM pending_results
+		WHERE tournament_id = ? AND board_number = ? AND ns_pair_id = ?`, tournamentId, boardNumber, nsPairId)
+	p, err := scanPendingResult(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending result: %w", notFound(err, fmt.Sprintf("board %d pair %s", boardNumber, nsPairId)))
+	}
+	return p, nil
+}
+
+func (s *SQLStore) SetPendingResult(ctx context.Context, p types.PendingResult) error {
+	_, err := s.DB.ExecContext(ctx, "INSERT OR REPLACE INTO pending_results ("+pendingResultColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.BoardNumber, p.Vul, p.Contract, p.Direction, p.Result, p.NSPairId, p.EWPairId,
+		p.TournamentId, p.Score, p.Adjustment, p.NSArtificial, p.EWArtificial, p.EWScore, p.FoulGroup,
+		p.DeclarerSeat, p.SubmittedBy, p.Status, p.DisputeReason)
+	return err
+}
+
+func (s *SQLStore) DeletePendingResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (bool, error) {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM pending_results WHERE tournament_id = ? AND board_number = ? AND ns_pair_id = ?",
+		tournamentId, boardNumber, nsPairId)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := res.RowsAffected()
+	return deleted > 0, err
+}
+
+func (s *SQLStore) GetPairBoardResults(ctx context.Context, tournamentId string, pairId string) ([]types.PairResultByBoard, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT board_number, contract, result, direction, raw_score, percentage, imps, datum,
+		par_score, par_contract FROM pair_board_results WHERE tournament_id = ? AND pair_id = ? ORDER BY board_number`, tournamentId, pairId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch board results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []types.PairResultByBoard
+	for rows.Next() {
+		var r types.PairResultByBoard
+		if err := rows.Scan(&r.BoardNumber, &r.Contract, &r.Result, &r.Direction, &r.RawScore, &r.Percentage, &r.IMPs, &r.Datum,
+			&r.ParScore, &r.ParContract); err != nil {
+			return nil, fmt.Errorf("failed to read board result: %w", err)
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func (s *SQLStore) SetPairBoardResult(ctx context.Context, tournamentId string, pairId string, r types.PairResultByBoard) error {
+	_, err := s.DB.ExecContext(ctx, `INSERT OR REPLACE INTO pair_board_results (tournament_id, pair_id, board_number,
+		contract, result, direction, raw_score, percentage, imps, datum, par_score, par_contract)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		tournamentId, pairId, r.BoardNumber, r.Contract, r.Result, r.Direction, r.RawScore, r.Percentage, r.IMPs, r.Datum,
+		r.ParScore, r.ParContract)
+	return err
+}
+
+func (s *SQLStore) DeletePairBoardResult(ctx context.Context, tournamentId string, pairId string, boardNumber int) error {
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM pair_board_results WHERE tournament_id = ? AND pair_id = ? AND board_number = ?",
+		tournamentId, pairId, boardNumber)
+	return err
+}
+
+//before and after are kept as JSON, null when there is no result
+func (s *SQLStore) AddAuditEntry(ctx context.Context, entry types.AuditEntry) error {
+	before, err := json.Marshal(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(entry.After)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx, `INSERT INTO audit_log (tournament_id, time, director, action, board_number,
+		ns_pair_id, before, after, reason) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.TournamentId, entry.Time.UTC().Format(time.RFC3339Nano), entry.Director, entry.Action, entry.BoardNumber,
+		entry.NSPairId, string(before), string(after), entry.Reason)
+	return err
+}
+
+func (s *SQLStore) GetAuditLog(ctx context.Context, tournamentId string) ([]types.AuditEntry, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT time, director, action, board_number, ns_pair_id, before, after, reason
+		FROM audit_log WHERE tournament_id = ? ORDER BY id`, tournamentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []types.AuditEntry
+	for rows.Next() {
+		entry := types.AuditEntry{TournamentId: tournamentId}
+		var at, before, after string
+		err := rows.Scan(&at, &entry.Director, &entry.Action, &entry.BoardNumber, &entry.NSPairId, &before, &after, &entry.Reason)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit entry: %w", err)
+		}
+		if entry.Time, err = time.Parse(time.RFC3339Nano, at); err != nil {
+			return nil, fmt.Errorf("invalid audit time %s: %w", at, err)
+		}
+		if err := json.Unmarshal([]byte(before), &entry.Before); err != nil {
+			return nil, fmt.Errorf("invalid audit entry: %w", err)
+		}
+		if err := json.Unmarshal([]byte(after), &entry.After); err != nil {
+			return nil, fmt.Errorf("invalid audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *SQLStore) GetLeaderboard(ctx context.Context, tournamentId string) ([]types.LeaderboardEntry, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT section, rank, pair_id, name1, name2, mp_score, percentage, imps, vps
+		FROM leaderboards WHERE tournament_id = ? ORDER BY section, rank`, tournamentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []types.LeaderboardEntry
+	for rows.Next() {
+		var e types.LeaderboardEntry
+		if err := rows.Scan(&e.Section, &e.Rank, &e.PairId, &e.Name1, &e.Name2, &e.MPScore, &e.Percentage, &e.IMPs, &e.VPs); err != nil {
+			return nil, fmt.Errorf("failed to read leaderboard: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *SQLStore) SetLeaderboard(ctx context.Context, tournamentId string, entries []types.LeaderboardEntry) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM leaderboards WHERE tournament_id = ?", tournamentId); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		_, err := tx.ExecContext(ctx, `INSERT INTO leaderboards (tournament_id, section, rank, pair_id, name1, name2,
+			mp_score, percentage, imps, vps) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			tournamentId, e.Section, e.Rank, e.PairId, e.Name1, e.Name2, e.MPScore, e.Percentage, e.IMPs, e.VPs)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) ListTournaments(ctx context.Context) ([]types.Tournament, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT id FROM tournaments ORDER BY created_at, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tournaments: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var tournaments []types.Tournament
+	for _, id := range ids {
+		t, err := s.GetTournament(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		tournaments = append(tournaments, *t)
+	}
+	return tournaments, nil
+}
+
+func (s *SQLStore) AppendEvent(ctx context.Context, event types.Event) (types.Event, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return event, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(seq), 0) + 1 FROM events WHERE tournament_id = ?", event.TournamentId).Scan(&event.Seq)
+	if err != nil {
+		return event, err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO events (tournament_id, seq, type, time, data) VALUES (?, ?, ?, ?, ?)",
+		event.TournamentId, event.Seq, event.Type, event.Time.UTC().Format(time.RFC3339Nano), string(event.Data))
+	if err != nil {
+		return event, err
+	}
+	return event, tx.Commit()
+}
+
+func (s *SQLStore) GetEvents(ctx context.Context, tournamentId string) ([]types.Event, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT seq, type, time, data FROM events WHERE tournament_id = ? ORDER BY seq", tournamentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []types.Event
+	for rows.Next() {
+		event := types.Event{TournamentId: tournamentId}
+		var at, data string
+		if err := rows.Scan(&event.Seq, &event.Type, &at, &data); err != nil {
+			return nil, fmt.Errorf("failed to read event: %w", err)
+		}
+		if event.Time, err = time.Parse(time.RFC3339Nano, at); err != nil {
+			return nil, fmt.Errorf("invalid event time %s: %w", at, err)
+		}
+		event.Data = json.RawMessage(data)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLStore) ResetTournament(ctx context.Context, tournamentId string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tables := []string{"pairs", "teams", "counters", "board_states", "board_results", "pending_results",
+		"pair_board_results", "finished_pairs", "leaderboards"}
+	for _, table := range tables {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE tournament_id = ?", tournamentId); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) FinishPair(ctx context.Context, tournamentId string, pairId string) (int, bool, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO finished_pairs (tournament_id, pair_id) VALUES (?, ?)", tournamentId, pairId)
+	if err != nil {
+		return 0, false, err
+	}
+	added, err := res.RowsAffected()
+	if err != nil {
+		return 0, false, err
+	}
+	var count int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM finished_pairs WHERE tournament_id = ?", tournamentId).Scan(&count)
+	if err != nil {
+		return 0, false, err
+	}
+	return count, added > 0, tx.Commit()
+}
+
+func (s *SQLStore) IsPairFinished(ctx context.Context, tournamentId string, pairId string) (bool, error) {
+	var count int
+	err := s.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM finished_pairs WHERE tournament_id = ? AND pair_id = ?",
+		tournamentId, pairId).Scan(&count)
+	return count > 0, err
+}
+
+func (s *SQLStore) FinishedPairCount(ctx context.Context, tournamentId string) (int, error) {
+	var count int
+	err := s.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM finished_pairs WHERE tournament_id = ?", tournamentId).Scan(&count)
+	return count, err
+}
+
+const dealColumns = "board_number, dealer, vulnerability, north, east, south, west, analysis"
+
+func scanDeal(row scanner, tournamentId string) (*types.Deal, error) {
+	deal := types.Deal{TournamentId: tournamentId}
+	var analysis string
+	err := row.Scan(&deal.BoardNumber, &deal.Dealer, &deal.Vulnerability, &deal.North, &deal.East, &deal.South, &deal.West, &analysis)
+	if err != nil {
+		return nil, err
+	}
+	if analysis != "" {
+		deal.Analysis = &types.Analysis{}
+		if err := json.Unmarshal([]byte(analysis), deal.Analysis); err != nil {
+			return nil, err
+		}
+	}
+	return &deal, nil
+}
+
+func (s *SQLStore) GetDeal(ctx context.Context, tournamentId string, boardNumber int) (*types.Deal, error) {
+	row := s.DB.QueryRowContext(ctx, "SELECT "+dealColumns+" FROM deals WHERE tournament_id = ? AND board_number = ?",
+		tournamentId, boardNumber)
+	deal, err := scanDeal(row, tournamentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deal: %w", notFound(err, fmt.Sprintf("board %d", boardNumber)))
+	}
+	return deal, nil
+}
+
+func (s *SQLStore) GetDeals(ctx context.Context, tournamentId string) ([]types.Deal, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT "+dealColumns+" FROM deals WHERE tournament_id = ? ORDER BY board_number",
+		tournamentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deals: %w", err)
+	}
+	defer rows.Close()
+
+	var deals []types.Deal
+	for rows.Next() {
+		deal, err := scanDeal(rows, tournamentId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read deal: %w", err)
+		}
+		deals = append(deals, *deal)
+	}
+	return deals, rows.Err()
+}
+
+func (s *SQLStore) SetDeal(ctx context.Context, deal types.Deal) error {
+	analysis := ""
+	if deal.Analysis != nil {
+		data, err := json.Marshal(deal.Analysis)
+		if err != nil {
+			return err
+		}
+		analysis = string(data)
+	}
+	_, err := s.DB.ExecContext(ctx, "INSERT OR REPLACE INTO deals (tournament_id, "+dealColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		deal.TournamentId, deal.BoardNumber, deal.Dealer, deal.Vulnerability, deal.North, deal.East, deal.South, deal.West, analysis)
+	return err
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"src/types"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Store is everything the API keeps about a tournament. Redis is used in
+// production, the in-memory store runs the API with no external services.
+type Store interface {
+	GetTournament(ctx context.Context, tournamentId string) (*types.Tournament, error)
+	SetTournament(ctx context.Context, tournament types.Tournament) error
+
+	GetPair(ctx context.Context, tournamentId string, pairId string) (*types.Pair, error)
+	SetPair(ctx context.Context, pair types.Pair) error
+	//pair and team numbers are handed out in the order they register
+	NextPairNumber(ctx context.Context, tournamentId string) (int, error)
+	ReleasePairNumber(ctx context.Context, tournamentId string) error
+
+	//a stored team has no pairs, they are stored as pairs
+	GetTeam(ctx context.Context, tournamentId string, teamId string) (*types.Team, error)
+	SetTeam(ctx context.Context, team types.Team) error
+	NextTeamNumber(ctx context.Context, tournamentId string) (int, error)
+	ReleaseTeamNumber(ctx context.Context, tournamentId string) error
+
+	GetBoardState(ctx context.Context, tournamentId string, pairId string) (*types.BoardState, error)
+	SetBoardState(ctx context.Context, tournamentId string, pairId string, state types.BoardState) error
+
+	//results are stored once per table, under the NS pair
+	GetBoardResults(ctx context.Context, tournamentId string) ([]types.BoardResult, error)
+	GetBoardResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (*types.BoardResult, error)
+	SetBoardResult(ctx context.Context, res types.BoardResult) error
+	DeleteBoardResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) error
+
+	//results waiting for the opponents to confirm them, or for the director once disputed
+	GetPendingResults(ctx context.Context, tournamentId string) ([]types.PendingResult, error)
+	GetPendingResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (*types.PendingResult, error)
+	SetPendingResult(ctx context.Context, pending types.PendingResult) error
+	//reports whether there was a result to delete, so only one of two racing confirmations records it
+	DeletePendingResult(ctx context.Context, tournamentId string, boardNumber int, nsPairId string) (bool, error)
+
+	//per board scores of a pair, rewritten every time the leaderboard is calculated
+	GetPairBoardResults(ctx context.Context, tournamentId string, pairId string) ([]types.PairResultByBoard, error)
+	SetPairBoardResult(ctx context.Context, tournamentId string, pairId string, result types.PairResultByBoard) error
+	DeletePairBoardResult(ctx context.Context, tournamentId string, pairId string, boardNumber int) error
+
+	//changes directors made to recorded results, oldest first
+	AddAuditEntry(ctx context.Context, entry types.AuditEntry) error
+	GetAuditLog(ctx context.Context, tournamentId string) ([]types.AuditEntry, error)
+
+	//final rankings, written whenever the results of a finished tournament are calculated
+	GetLeaderboard(ctx context.Context, tournamentId string) ([]types.LeaderboardEntry, error)
+	SetLeaderboard(ctx context.Context, tournamentId string, entries []types.LeaderboardEntry) error
+	ListTournaments(ctx context.Context) ([]types.Tournament, error)
+
+	//the log of every command, AppendEvent numbers the event and returns it
+	AppendEvent(ctx context.Context, event types.Event) (types.Event, error)
+	GetEvents(ctx context.Context, tournamentId string) ([]types.Event, error)
+	//clears everything built up by a tournament's events so they can be replayed,
+	//the tournament itself, its deals, event log and audit log are kept
+	ResetTournament(ctx context.Context, tournamentId string) error
+
+	//hands imported for each board
+	GetDeal(ctx context.Context, tournamentId string, boardNumber int) (*types.Deal, error)
+	GetDeals(ctx context.Context, tournamentId string) ([]types.Deal, error)
+	SetDeal(ctx context.Context, deal types.Deal) error
+
+	//returns how many pairs have finished, and false if this pair already had
+	FinishPair(ctx context.Context, tournamentId string, pairId string) (int, bool, error)
+	IsPairFinished(ctx context.Context, tournamentId string, pairId string) (bool, error)
+	FinishedPairCount(ctx context.Context, tournamentId string) (int, error)
+}
+
+func tournamentKey(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s", tournamentId)
+}
+
+func leaderboardKey(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:leaderboard", tournamentId)
+}
+
+const tournamentsKey = "tournaments"
+
+func pairKey(tournamentId string, pairId string) string {
+	return fmt.Sprintf("tournament:%s:pair:%s", tournamentId, pairId)
+}
+
+func pairStateKey(tournamentId string, pairId string) string {
+	return fmt.Sprintf("tournament:%s:pair:%s:state", tournamentId, pairId)
+}
+
+func pairBoardResultsKey(tournamentId string, pairId string) string {
+	return fmt.Sprintf("tournament:%s:pair:%s:boardResults", tournamentId, pairId)
+}
+
+func pairCounterKey(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:pair_counter", tournamentId)
+}
+
+func teamKey(tournamentId string, teamId string) string {
+	return fmt.Sprintf("tournament:%s:team:%s", tournamentId, teamId)
+}
+
+func teamCounterKey(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:team_counter", tournamentId)
+}
+
+func boardResultKey(tournamentId string, boardNumber int, nsPairId string) string {
+	return fmt.Sprintf("tournament:%s:board:%d:pair:%s", tournamentId, boardNumber, nsPairId)
+}
+
+func boardResultPattern(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:board:*", tournamentId)
+}
+
+//kept apart from tournament:<id>:board:* so pending results are never scored
+func pendingResultKey(tournamentId string, boardNumber int, nsPairId string) string {
+	return fmt.Sprintf("tournament:%s:pending:%d:pair:%s", tournamentId, boardNumber, nsPairId)
+}
+
+func pendingResultPattern(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:pending:*", tournamentId)
+}
+
+func auditKey(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:audit", tournamentId)
+}
+
+func eventsKey(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:events", tournamentId)
+}
+
+func eventCounterKey(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:event_counter", tournamentId)
+}
+
+//not tournament:<id>:board:*, which holds the results
+func dealKey(tournamentId string, boardNumber int) string {
+	return fmt.Sprintf("tournament:%s:deal:%d", tournamentId, boardNumber)
+}
+
+func dealPattern(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:deal:*", tournamentId)
+}
+
+func finishedPairsKey(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:finished_pairs", tournamentId)
+}
+
+func finishedCounterKey(tournamentId string) string {
+	return fmt.Sprintf("tournament:%s:pairs_finished_counter", tournamentId)
+}
+
+func boardField(boardNumber int) string {
+	return fmt.Sprintf("board:%d", boardNumber)
+}
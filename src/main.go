@@ -5,18 +5,15 @@ import (
 	"net/http"
 	"src/apis"
 	"src/database"
-	"context"
 )
 
 func main(){
-	//Init DB
-	redisCli := database.LoadRedis()
-	ctx := context.Background()
-	redisCli.FlushAll(ctx)
+	//Init DB, STORE picks sqlite (default), redis or memory
+	store := database.LoadStore()
 
 	//Rest API
 	mux := http.NewServeMux()
-	api.Routes(mux,redisCli)
+	api.Routes(mux,store)
 	fmt.Println("Listening on port 8080")
 	http.ListenAndServe(":8080",mux)
-}
\ No newline at end of file
+}
@@ -1,11 +1,18 @@
 package types
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type MatchpointScore struct {
 	PairID    string
 	Direction string
 	MPScore   float64
 	RawScore  int
 	Percentage float64
+	IMPs float64
+	Datum int
 	Contract string
 	ContractDirection string
 	Result string
@@ -16,9 +23,130 @@ type BoardResult struct {
 	Vul          string
 	Contract     string
 	Direction    string
+	DeclarerSeat string  //N, E, S or W when the table enters it, on the Direction side
 	Result       string
 	NSPairId     string
 	EWPairId     string
 	TournamentId string
 	Score        int 
-}
\ No newline at end of file
+	Adjustment   string  //"", Artificial or Assigned
+	NSArtificial string  //A+, A= or A- on an artificial score
+	EWArtificial string
+	EWScore      int  //NS point of view score EW get on a split assigned score
+	FoulGroup    int  //which deal was played when the board was fouled, 0 if it was not
+}
+type Tournament struct {
+	Id                string
+	BoardsPerRound    int
+	TotalRounds       int
+	Type              int
+	Teams             int     //# of Pairs if pair game
+	ScoringMethod     int
+	Movement          int
+	ArrowSwitchRounds []int   //rounds in which NS and EW swap seats
+	AveragePlus       float64 //percentages for artificial scores, 0 for the 60/50/40 default
+	Average           float64
+	AverageMinus      float64
+	LiveStandings     bool //standings go out after every result, not only after each round
+}
+
+type Pair struct {
+	Id           string //1NS or 4EW, just the pair number in a Howell
+	Name1        string
+	Name2        string
+	TournamentId string
+	TeamId       string //only set in a team game
+	SecretHash   string //SHA-256 of the secret the pair was given when it registered
+}
+
+type Team struct {
+	Id           string //team number, pair ids are <Id>NS and <Id>EW
+	Name         string
+	TournamentId string
+	Pairs        []Pair
+}
+
+type BoardState struct {
+	CurrentBoard int
+	CurrentOpp   string
+	CurrentRound int
+	Direction    string
+	Room         string //Open or Closed in a team game
+	IsSitOut     bool
+}
+
+type PairResultByBoard struct {
+	BoardNumber int
+	Contract    string
+	Result      string
+	Direction   string
+	RawScore    int
+	Percentage  float64
+	IMPs        float64
+	Datum       int
+	ParScore    int    //from this pair's side, to compare with RawScore
+	ParContract string //blank until the board's hands have been solved
+}
+
+//a line of a final ranking, kept as tournament history
+type LeaderboardEntry struct {
+	Section    string //Overall, NS, EW or Teams
+	Rank       int
+	PairId     string //team id in a team game
+	Name1      string //team name in a team game
+	Name2      string
+	MPScore    float64
+	Percentage float64
+	IMPs       float64
+	VPs        float64
+}
+
+//a result entered by one pair, scored once the other pair confirms it
+type PendingResult struct {
+	BoardResult
+	SubmittedBy   string //pair that entered the result
+	Status        string //Pending, or Disputed once it is waiting for the director
+	DisputeReason string
+}
+
+//a director's change to a recorded result
+type AuditEntry struct {
+	TournamentId string
+	Time         time.Time
+	Director     string
+	Action       string //Edit or Delete
+	BoardNumber  int
+	NSPairId     string
+	Before       *BoardResult
+	After        *BoardResult //nil when the result was removed
+	Reason       string
+}
+
+//one command in a tournament's append-only log, Data holds what the command was given
+type Event struct {
+	TournamentId string
+	Seq          int //position in the log, starting at 1
+	Type         string
+	Time         time.Time
+	Data         json.RawMessage
+}
+
+//the cards of a board, each hand written spades.hearts.diamonds.clubs as in PBN, e.g. "AK32.Q4.J952.T8"
+type Deal struct {
+	TournamentId  string
+	BoardNumber   int
+	Dealer        string //N, E, S or W
+	Vulnerability string //None, NS, EW or All
+	North         string
+	East          string
+	South         string
+	West          string
+	Analysis      *Analysis //nil until the hands have been solved
+}
+
+//double-dummy tricks and par of a deal
+type Analysis struct {
+	Tricks      [4][5]int //by declarer N, E, S, W and strain S, H, D, C, NT
+	ParScore    int       //from NS's side
+	ParContract string    //e.g. "4S by N" or "5DX by EW"
+}
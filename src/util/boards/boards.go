@@ -0,0 +1,56 @@
+package boards
+
+import (
+	"fmt"
+)
+
+const (
+	VulNone = iota
+	VulNS
+	VulEW
+	VulAll
+)
+
+var dealers = []string{"N", "E", "S", "W"}
+
+var vulNames = []string{"None", "NS", "EW", "All"}
+
+// Info is the fixed metadata printed on a duplicate board.
+type Info struct {
+	BoardNumber   int
+	Dealer        string //N, E, S or W
+	Vulnerability int
+	VulName       string //None, NS, EW or All
+}
+
+// Dealer rotates N, E, S, W from board 1.
+func Dealer(boardNumber int) string {
+	return dealers[(boardNumber-1)%4]
+}
+
+// Vulnerability follows the standard 16 board cycle, where each group of
+// four boards shifts the None, NS, EW, All pattern along by one.
+func Vulnerability(boardNumber int) int {
+	n := boardNumber - 1
+	return (n%4 + (n/4)%4) % 4
+}
+
+func VulName(vul int) string {
+	if vul < VulNone || vul > VulAll {
+		return ""
+	}
+	return vulNames[vul]
+}
+
+func Get(boardNumber int) (Info, error) {
+	if boardNumber < 1 {
+		return Info{}, fmt.Errorf("invalid board number %d", boardNumber)
+	}
+	vul := Vulnerability(boardNumber)
+	return Info{
+		BoardNumber:   boardNumber,
+		Dealer:        Dealer(boardNumber),
+		Vulnerability: vul,
+		VulName:       VulName(vul),
+	}, nil
+}
@@ -0,0 +1,85 @@
+package dds
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Strains in the order of a trick table. Suits are also the order cards are
+// kept in a hand.
+const (
+	Spades = iota
+	Hearts
+	Diamonds
+	Clubs
+	NoTrump
+)
+
+// Seats clockwise from North, North and South are one side.
+const (
+	North = iota
+	East
+	South
+	West
+)
+
+var (
+	ErrInvalidHand = errors.New("hand must be four suits of spades, hearts, diamonds and clubs")
+	ErrInvalidDeal = errors.New("every hand must hold the same number of cards and every card be dealt once")
+)
+
+const ranks = "23456789TJQKA"
+
+// A hand is a bit per card, 16 bits per suit with the 2 at bit 2 and the ace at bit 14.
+type hand uint64
+
+func card(suit int, rank int) int {
+	return suit*16 + rank
+}
+
+func suitOf(c int) int {
+	return c / 16
+}
+
+func (h hand) suit(suit int) uint64 {
+	return uint64(h>>(suit*16)) & 0xFFFF
+}
+
+// Deal holds the four hands, North first.
+type Deal [4]hand
+
+// ParseDeal reads the hands of North, East, South and West written
+// spades.hearts.diamonds.clubs, as in a PBN Deal tag.
+func ParseDeal(hands [4]string) (Deal, error) {
+	var deal Deal
+	var dealt hand
+	for seat, text := range hands {
+		suits := strings.Split(strings.ToUpper(strings.TrimSpace(text)), ".")
+		if len(suits) != 4 {
+			return deal, fmt.Errorf("%w: %q", ErrInvalidHand, text)
+		}
+		for suit, cards := range suits {
+			for _, r := range strings.ReplaceAll(cards, "10", "T") {
+				rank := strings.IndexRune(ranks, r)
+				if rank < 0 {
+					return deal, fmt.Errorf("%w: %q", ErrInvalidHand, text)
+				}
+				bit := hand(1) << card(suit, rank+2)
+				if dealt&bit != 0 {
+					return deal, fmt.Errorf("%w: %c%c is dealt twice", ErrInvalidDeal, "SHDC"[suit], r)
+				}
+				dealt |= bit
+				deal[seat] |= bit
+			}
+		}
+	}
+	count := bits.OnesCount64(uint64(deal[North]))
+	for _, h := range deal {
+		if bits.OnesCount64(uint64(h)) != count || count == 0 {
+			return deal, ErrInvalidDeal
+		}
+	}
+	return deal, nil
+}
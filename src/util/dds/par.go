@@ -0,0 +1,120 @@
+package dds
+
+import (
+	"fmt"
+	"src/util/scoring"
+	"strings"
+)
+
+// Strains from the lowest bid up, and how they are written in a contract.
+var (
+	biddingOrder = []int{Clubs, Diamonds, Hearts, Spades, NoTrump}
+	strainNames  = []string{"S", "H", "D", "C", "NT"}
+	seatNames    = []string{"N", "E", "S", "W"}
+)
+
+// Par is the result of a board when both sides bid and play it perfectly:
+// a side that can make a contract bids it, and the other side outbids it
+// whenever going down doubled costs less.
+type Par struct {
+	Score    int    //from North-South's side
+	Contract string //e.g. 4S by N, 5DX by EW, or PASS
+}
+
+// One contract a side can bid, with the score it comes to from
+// North-South's side, doubled when it goes down.
+type bid struct {
+	contract string
+	score    int
+}
+
+// The best contract for each side at every level and strain, indexed by
+// side and then by how high the bid is.
+func bids(table TrickTable, vulnerable int) ([2][35]bid, error) {
+	var all [2][35]bid
+	for side := 0; side < 2; side++ {
+		direction := []string{"NS", "EW"}[side]
+		for i := 0; i < 35; i++ {
+			level, strain := i/5+1, biddingOrder[i%5]
+			tricks := max(table[side][strain], table[side+2][strain])
+			var declarers string
+			for _, seat := range []int{side, side + 2} {
+				if table[seat][strain] == tricks {
+					declarers += seatNames[seat]
+				}
+			}
+
+			contract := fmt.Sprintf("%d%s", level, strainNames[strain])
+			result := "="
+			if over := tricks - level - 6; over > 0 {
+				result = fmt.Sprintf("+%d", over)
+			} else if over < 0 {
+				contract += "X"
+				result = fmt.Sprintf("%d", over)
+			}
+			score, err := scoring.CalculateScore(contract, direction, result, vulnerable)
+			if err != nil {
+				return all, err
+			}
+			if side == 1 {
+				score = -score
+			}
+			all[side][i] = bid{contract: contract + " by " + declarers, score: score}
+		}
+	}
+	return all, nil
+}
+
+// CalculatePar works out par from a trick table, with vulnerable as one of
+// the boards vulnerability values and the dealer's seat as N, E, S or W.
+// The dealer's side gets the first chance to bid, which only matters when
+// both sides can make the same contract.
+func CalculatePar(table TrickTable, vulnerable int, dealer string) (Par, error) {
+	all, err := bids(table, vulnerable)
+	if err != nil {
+		return Par{}, err
+	}
+	first := strings.Index("NESW", strings.ToUpper(dealer))
+	if first < 0 {
+		return Par{}, fmt.Errorf("invalid dealer %q", dealer)
+	}
+	first %= 2
+
+	//North-South want the score as high as it goes, East-West as low
+	better := func(side int, a int, b int) bool {
+		if side == 0 {
+			return a > b
+		}
+		return a < b
+	}
+
+	//outcome[side][i] is where it ends once side has bid i and the other
+	//side either passes or outbids it, and final is the contract it ends in;
+	//a side that loses nothing by outbidding does, so 1D is not par when 2C would push it to 2D
+	var outcome [2][36]int
+	var final [2][36]string
+	for i := 34; i >= 0; i-- {
+		for side := 0; side < 2; side++ {
+			other := 1 - side
+			outcome[side][i], final[side][i] = all[side][i].score, all[side][i].contract
+			for j := i + 1; j < 35; j++ {
+				if !better(other, outcome[side][i], outcome[other][j]) {
+					outcome[side][i], final[side][i] = outcome[other][j], final[other][j]
+				}
+			}
+		}
+	}
+
+	//the side that speaks first can pass, leaving the other side to open or pass it out
+	opening := func(side int, score int, contract string) (int, string) {
+		for i := 0; i < 35; i++ {
+			if better(side, outcome[side][i], score) {
+				score, contract = outcome[side][i], final[side][i]
+			}
+		}
+		return score, contract
+	}
+	score, contract := opening(1-first, 0, scoring.PassedOut)
+	score, contract = opening(first, score, contract)
+	return Par{Score: score, Contract: contract}, nil
+}
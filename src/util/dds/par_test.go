@@ -0,0 +1,84 @@
+package dds
+
+import (
+	"src/util/boards"
+	"testing"
+)
+
+// A table where every declarer takes 6 tricks in every strain, for the
+// tests to change.
+func flatTable() TrickTable {
+	var table TrickTable
+	for seat := range table {
+		for strain := range table[seat] {
+			table[seat][strain] = 6
+		}
+	}
+	return table
+}
+
+func TestCalculatePar(t *testing.T) {
+	passedOut := flatTable()
+
+	//North-South make 4S, East-West go two down in 5H
+	sacrifice := TrickTable{{10, 4, 6, 6, 8}, {3, 9, 6, 6, 5}, {10, 4, 6, 6, 8}, {3, 9, 6, 6, 5}}
+	//North-South make 4S, East-West go three down in 5H
+	threeDown := TrickTable{{10, 4, 6, 6, 8}, {3, 8, 6, 6, 5}, {10, 4, 6, 6, 8}, {3, 8, 6, 6, 5}}
+	//North-South make 6S, East-West go four down in 7H
+	fourDown := TrickTable{{12, 4, 6, 6, 8}, {3, 9, 6, 6, 5}, {12, 4, 6, 6, 8}, {3, 9, 6, 6, 5}}
+
+	//both sides make eight tricks in their own minor, and diamonds outrank clubs
+	minors := flatTable()
+	for _, seat := range []int{North, South} {
+		minors[seat][Clubs], minors[seat][Diamonds] = 8, 5
+	}
+	for _, seat := range []int{East, West} {
+		minors[seat][Clubs], minors[seat][Diamonds] = 5, 8
+	}
+
+	//both sides make 1NT, so it is the dealer's side's
+	notrump := flatTable()
+	for seat := range notrump {
+		notrump[seat][NoTrump] = 7
+	}
+
+	tests := []struct {
+		name       string
+		table      TrickTable
+		vulnerable int
+		dealer     string
+		want       Par
+	}{
+		{"passed out", passedOut, boards.VulAll, "N", Par{0, "PASS"}},
+		{"sacrifice none vulnerable", sacrifice, boards.VulNone, "N", Par{300, "5HX by EW"}},
+		{"sacrifice against vulnerable game", sacrifice, boards.VulNS, "N", Par{300, "5HX by EW"}},
+		{"sacrifice too dear", sacrifice, boards.VulEW, "N", Par{420, "4S by NS"}},
+		{"sacrifice all vulnerable", sacrifice, boards.VulAll, "N", Par{500, "5HX by EW"}},
+		{"three down against vulnerable game", threeDown, boards.VulNS, "N", Par{500, "5HX by EW"}},
+		{"three down against game", threeDown, boards.VulNone, "N", Par{420, "4S by NS"}},
+		{"four down against vulnerable slam", fourDown, boards.VulNS, "N", Par{800, "7HX by EW"}},
+		{"higher minor", minors, boards.VulNone, "N", Par{-90, "2D by EW"}},
+		{"higher minor dealer East", minors, boards.VulNone, "E", Par{-90, "2D by EW"}},
+		{"equal score dealer North", notrump, boards.VulNone, "N", Par{90, "1NT by NS"}},
+		{"equal score dealer East", notrump, boards.VulNone, "E", Par{-90, "1NT by EW"}},
+		{"equal score dealer South", notrump, boards.VulNone, "s", Par{90, "1NT by NS"}},
+		{"equal score dealer West", notrump, boards.VulNone, "W", Par{-90, "1NT by EW"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := CalculatePar(test.table, test.vulnerable, test.dealer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("CalculatePar = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCalculateParInvalidDealer(t *testing.T) {
+	if _, err := CalculatePar(flatTable(), boards.VulNone, "X"); err == nil {
+		t.Error("CalculatePar took dealer X")
+	}
+}
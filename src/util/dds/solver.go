@@ -0,0 +1,555 @@
+package dds
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// Bounds are kept for positions with the same suit lengths in every hand.
+// A bound holds for any of them whose top cards in each suit are held the
+// same way, down to the lowest card whose rank decided a trick in the search
+// behind it. The rest are small cards that only follow suit.
+type lengths struct {
+	suits  uint64
+	leader int8
+}
+
+type bound struct {
+	owners [4]uint32 //who holds each of the top cards, two bits a card
+	top    [4]uint8  //how many of the top cards in each suit matter
+	tricks int8
+	upper  bool //tricks is the most North-South take, otherwise the least
+}
+
+// Keeps the bounds of a few positions per set of lengths, the latest last.
+const boundsPerLengths = 16
+
+// Bounds kept per strain before the table is cleared.
+const maxBounds = 1 << 20
+
+type solver struct {
+	hands  Deal
+	all    hand //every card still in a hand
+	trump  int
+	known  map[lengths]*known
+	stored int
+}
+
+type known struct {
+	bounds []bound
+	lead   int8 //the lead that last did best, as a suit and how many cards are above it
+}
+
+// The cards played to the trick so far, from the leader round.
+type trick struct {
+	leader int
+	cards  [4]int
+}
+
+func newSolver(deal Deal, strain int) *solver {
+	return &solver{
+		hands: deal,
+		all:   deal[North] | deal[East] | deal[South] | deal[West],
+		trump: strain,
+		known: make(map[lengths]*known),
+	}
+}
+
+// squeeze[mask][x] packs the bits of x that are in mask into the low bits,
+// seven bits at a time.
+var squeeze [128][128]uint8
+
+func init() {
+	for mask := 0; mask < 128; mask++ {
+		for x := 0; x < 128; x++ {
+			packed, next := 0, 0
+			for bit := 0; bit < 7; bit++ {
+				if mask&(1<<bit) != 0 {
+					packed |= (x >> bit & 1) << next
+					next++
+				}
+			}
+			squeeze[mask][x] = uint8(packed)
+		}
+	}
+}
+
+// Packs the ranks of a suit held in x down to the cards left in the suit.
+func compress(x uint64, cards uint64) uint32 {
+	x, cards = x>>2, cards>>2
+	low := uint32(squeeze[cards&127][x&127])
+	return low | uint32(squeeze[cards>>7][x>>7&127])<<bits.OnesCount64(cards&127)
+}
+
+// Spreads the bits of x out to every other bit.
+func spread(x uint32) uint32 {
+	x = (x | x<<8) & 0x00FF00FF
+	x = (x | x<<4) & 0x0F0F0F0F
+	x = (x | x<<2) & 0x33333333
+	return (x | x<<1) & 0x55555555
+}
+
+// The lengths of every hand, and who holds each card of a suit from the top
+// down, two bits a card.
+func (s *solver) position(leader int) (lengths, [4]uint32) {
+	key := lengths{leader: int8(leader)}
+	var owners [4]uint32
+	odd, high := s.hands[East]|s.hands[West], s.hands[South]|s.hands[West]
+	for suit := Spades; suit <= Clubs; suit++ {
+		for seat := North; seat <= West; seat++ {
+			key.suits = key.suits<<4 | uint64(bits.OnesCount64(s.hands[seat].suit(suit)))
+		}
+		cards := s.all.suit(suit)
+		owners[suit] = spread(compress(odd.suit(suit), cards)) | spread(compress(high.suit(suit), cards))<<1
+	}
+	return key, owners
+}
+
+// Whether a bound was found for a position that settles target, and the
+// cards it rests on.
+func (s *solver) lookup(k *known, owners *[4]uint32, target int) (found bool, result bool, decided hand) {
+	var length [4]int
+	for suit := Spades; suit <= Clubs; suit++ {
+		length[suit] = bits.OnesCount64(s.all.suit(suit))
+	}
+	for _, b := range k.bounds {
+		if b.upper == (int(b.tricks) >= target) {
+			continue
+		}
+		if owners[0]>>(2*(length[0]-int(b.top[0]))) != b.owners[0] ||
+			owners[1]>>(2*(length[1]-int(b.top[1]))) != b.owners[1] ||
+			owners[2]>>(2*(length[2]-int(b.top[2]))) != b.owners[2] ||
+			owners[3]>>(2*(length[3]-int(b.top[3]))) != b.owners[3] {
+			continue
+		}
+		for suit := Spades; suit <= Clubs; suit++ {
+			decided |= s.topCards(suit, int(b.top[suit]))
+		}
+		return true, !b.upper, decided
+	}
+	return false, false, 0
+}
+
+func (s *solver) store(k *known, owners *[4]uint32, tricks int, upper bool, decided hand) {
+	b := bound{tricks: int8(tricks), upper: upper}
+	for suit := Spades; suit <= Clubs; suit++ {
+		cards := s.all.suit(suit)
+		low := decided.suit(suit)
+		if low == 0 {
+			continue
+		}
+		//everything from the lowest card that mattered up
+		top := bits.OnesCount64(cards >> bits.TrailingZeros64(low))
+		b.top[suit] = uint8(top)
+		b.owners[suit] = owners[suit] >> (2 * (bits.OnesCount64(cards) - top))
+	}
+	if len(k.bounds) >= boundsPerLengths {
+		copy(k.bounds, k.bounds[1:])
+		k.bounds[len(k.bounds)-1] = b
+		return
+	}
+	k.bounds = append(k.bounds, b)
+	s.stored++
+}
+
+// The top cards left in a suit.
+func (s *solver) topCards(suit int, count int) hand {
+	cards := s.all.suit(suit)
+	var top uint64
+	for ; count > 0 && cards != 0; count-- {
+		bit := uint64(1) << (bits.Len64(cards) - 1)
+		top |= bit
+		cards &^= bit
+	}
+	return hand(top) << (suit * 16)
+}
+
+// How many of the top cards left in a suit the player holds in a row.
+func (s *solver) run(player int, suit int) int {
+	mine := s.hands[player].suit(suit)
+	above := s.all.suit(suit) &^ mine
+	if above == 0 {
+		return bits.OnesCount64(mine)
+	}
+	return bits.OnesCount64(mine >> bits.Len64(above))
+}
+
+// Tricks a player can cash straight off from the top. Suits outside trumps
+// only count while both opponents follow, when they could ruff.
+func (s *solver) topTricks(player int) int {
+	left, right := s.hands[(player+1)%4], s.hands[(player+3)%4]
+	opponentsRuff := s.trump != NoTrump && (left|right).suit(s.trump) != 0
+	top := 0
+	for suit := Spades; suit <= Clubs; suit++ {
+		run := s.run(player, suit)
+		if opponentsRuff && suit != s.trump {
+			run = min(run, bits.OnesCount64(left.suit(suit)), bits.OnesCount64(right.suit(suit)))
+		}
+		top += run
+	}
+	return top
+}
+
+// Tricks the side on lead is sure of: the leader's winners, or partner's
+// when the leader can get across to them.
+func (s *solver) quickTricks(leader int) int {
+	quick := s.topTricks(leader)
+	partner := (leader + 2) % 4
+	left, right := s.hands[(leader+1)%4], s.hands[(leader+3)%4]
+	for suit := Spades; suit <= Clubs; suit++ {
+		if s.hands[leader].suit(suit) == 0 || s.run(partner, suit) == 0 {
+			continue
+		}
+		if s.trump != NoTrump && suit != s.trump && (left|right).suit(s.trump) != 0 &&
+			(left.suit(suit) == 0 || right.suit(suit) == 0) {
+			continue
+		}
+		return max(quick, s.topTricks(partner))
+	}
+	return quick
+}
+
+// Tricks a side is sure of from its top trumps. When one player holds them
+// all, a trump above everything else wins whenever it is played.
+func (s *solver) trumpTricks(side int) int {
+	if s.trump == NoTrump {
+		return 0
+	}
+	return max(s.run(side, s.trump), s.run(side+2, s.trump))
+}
+
+// Returns how many of the remaining tricks North-South take with leader on
+// lead, starting the search from a guess.
+func (s *solver) tricks(leader int, guess int) int {
+	remaining := bits.OnesCount64(uint64(s.hands[leader]))
+	guess = max(0, min(guess, remaining))
+	if ok, _ := s.canTake(leader, guess); ok {
+		for guess < remaining {
+			if ok, _ := s.canTake(leader, guess+1); !ok {
+				break
+			}
+			guess++
+		}
+		return guess
+	}
+	for {
+		if ok, _ := s.canTake(leader, guess-1); ok {
+			return guess - 1
+		}
+		guess--
+	}
+}
+
+// The top cards of each suit held in a run by the side, which is what the
+// quick tricks of the side rest on.
+func (s *solver) runs(side int) hand {
+	var decided hand
+	for suit := Spades; suit <= Clubs; suit++ {
+		decided |= s.topCards(suit, max(s.run(side, suit), s.run(side+2, suit)))
+	}
+	return decided
+}
+
+// Whether North-South can take target of the remaining tricks, and the
+// cards whose ranks decided it. Positions are looked up before they are
+// searched.
+func (s *solver) canTake(leader int, target int) (bool, hand) {
+	remaining := bits.OnesCount64(uint64(s.hands[leader]))
+	if target <= 0 {
+		return true, 0
+	}
+	if target > remaining {
+		return false, 0
+	}
+
+	//the last trick plays itself
+	if remaining == 1 {
+		t := trick{leader: leader}
+		for i := 0; i < 4; i++ {
+			t.cards[i] = bits.TrailingZeros64(uint64(s.hands[(leader+i)%4]))
+		}
+		winner := s.winner(&t, 4)
+		return winner%2 == North, s.byRank(&t, winner)
+	}
+
+	quick := s.quickTricks(leader)
+	if leader%2 == North && quick >= target {
+		return true, s.runs(leader % 2)
+	}
+	if leader%2 != North && target > remaining-quick {
+		return false, s.runs(leader % 2)
+	}
+	if s.trump != NoTrump {
+		if target <= s.trumpTricks(North) {
+			return true, s.topCards(s.trump, s.trumpTricks(North))
+		}
+		if target > remaining-s.trumpTricks(East) {
+			return false, s.topCards(s.trump, s.trumpTricks(East))
+		}
+	}
+
+	key, owners := s.position(leader)
+	k, ok := s.known[key]
+	if ok {
+		if found, result, decided := s.lookup(k, &owners, target); found {
+			return result, decided
+		}
+	} else {
+		//past this many bounds the table starts again, to keep the memory in check
+		if s.stored >= maxBounds {
+			s.known = make(map[lengths]*known)
+			s.stored = 0
+		}
+		k = &known{lead: -1}
+		s.known[key] = k
+	}
+
+	t := trick{leader: leader}
+	first := -1
+	if k.lead >= 0 {
+		first = s.fromTop(int(k.lead))
+	}
+	result, decided := s.play(&t, 0, target, first)
+	if result {
+		s.store(k, &owners, target, false, decided)
+	} else {
+		s.store(k, &owners, target-1, true, decided)
+	}
+	//a lead that gets the side on lead what it wants is the one to try first next time
+	if result == (leader%2 == North) {
+		k.lead = int8(s.toTop(t.cards[0]))
+	}
+	return result, decided
+}
+
+// A card as its suit and the number of cards left above it, so it can be
+// kept with a position.
+func (s *solver) toTop(c int) int {
+	return suitOf(c)*16 + bits.OnesCount64(s.all.suit(suitOf(c))>>(c%16+1))
+}
+
+func (s *solver) fromTop(lead int) int {
+	cards := s.all.suit(lead / 16)
+	for above := lead % 16; above > 0 && cards != 0; above-- {
+		cards &^= 1 << (bits.Len64(cards) - 1)
+	}
+	if cards == 0 {
+		return -1
+	}
+	return card(lead/16, bits.Len64(cards)-1)
+}
+
+// Plays the card of the pos'th player in the trick. North-South look for
+// any card that gets them to target, East-West for any that stops them.
+// The card first is tried first when it is one of the moves. Returns the
+// cards whose ranks decided it: those behind the card that got there, or
+// behind every card tried when none did.
+func (s *solver) play(t *trick, pos int, target int, first int) (bool, hand) {
+	player := (t.leader + pos) % 4
+	northSouth := player%2 == North
+	var moves [13]int
+	count := s.moves(t, pos, player, &moves)
+	for i := 1; i < count; i++ {
+		if moves[i] == first {
+			copy(moves[1:i+1], moves[:i])
+			moves[0] = first
+			break
+		}
+	}
+	var decided hand
+	for _, c := range moves[:count] {
+		bit := hand(1) << c
+		s.hands[player] &^= bit
+		s.all &^= bit
+		t.cards[pos] = c
+		var result bool
+		var behind hand
+		if pos == 3 {
+			winner := s.winner(t, 4)
+			next := target
+			if winner%2 == North {
+				next--
+			}
+			result, behind = s.canTake(winner, next)
+			behind |= s.byRank(t, winner)
+		} else {
+			result, behind = s.play(t, pos+1, target, -1)
+		}
+		s.hands[player] |= bit
+		s.all |= bit
+		if result == northSouth {
+			t.cards[pos] = c
+			return result, behind
+		}
+		decided |= behind
+	}
+	return !northSouth, decided
+}
+
+// The winning card when it won by rank, over another card of its suit.
+func (s *solver) byRank(t *trick, winner int) hand {
+	won := t.cards[(winner-t.leader+4)%4]
+	for _, c := range t.cards {
+		if c != won && suitOf(c) == suitOf(won) {
+			return hand(1) << won
+		}
+	}
+	return 0
+}
+
+// Seat winning the trick once its first played cards are down.
+func (s *solver) winner(t *trick, played int) int {
+	best := 0
+	for i := 1; i < played; i++ {
+		if s.beats(t.cards[i], t.cards[best]) {
+			best = i
+		}
+	}
+	return (t.leader + best) % 4
+}
+
+func (s *solver) beats(c int, winning int) bool {
+	if suitOf(c) == suitOf(winning) {
+		return c > winning
+	}
+	return suitOf(c) == s.trump
+}
+
+// Whether a player still to play could beat the winning card of a trick led in led.
+func (s *solver) canBeat(player int, led int, winning int) bool {
+	h := s.hands[player]
+	if h.suit(led) != 0 {
+		return suitOf(winning) == led && h.suit(led)>>(winning%16+1) != 0
+	}
+	if s.trump == NoTrump || h.suit(s.trump) == 0 {
+		return false
+	}
+	return suitOf(winning) != s.trump || h.suit(s.trump)>>(winning%16+1) != 0
+}
+
+// Lists the cards worth trying, best guesses first. Cards in a run, with
+// nothing left between them, are as good as each other so only the lowest
+// is tried.
+func (s *solver) moves(t *trick, pos int, player int, moves *[13]int) int {
+	h := s.hands[player]
+	present := s.all
+	first, last := Spades, Clubs
+	winning, partnerWinning := -1, false
+	if pos > 0 {
+		//cards on the table still separate the cards around them
+		for i := 0; i < pos; i++ {
+			present |= hand(1) << t.cards[i]
+		}
+		if led := suitOf(t.cards[0]); h.suit(led) != 0 {
+			first, last = led, led
+		}
+		winner := s.winner(t, pos)
+		winning = t.cards[(winner-t.leader+4)%4]
+		partnerWinning = winner%2 == player%2
+	}
+
+	var scores [13]int
+	count := 0
+	for suit := first; suit <= last; suit++ {
+		mine, cards := h.suit(suit), present.suit(suit)
+		for rest := mine; rest != 0; rest &= rest - 1 {
+			rank := bits.TrailingZeros64(rest)
+			below := cards & (1<<rank - 1)
+			if below != 0 && mine&(1<<(bits.Len64(below)-1)) != 0 {
+				continue
+			}
+			c := card(suit, rank)
+			moves[count] = c
+			if pos == 0 {
+				scores[count] = s.leadScore(player, c)
+			} else {
+				scores[count] = s.followScore(t, pos, player, c, winning, partnerWinning)
+			}
+			count++
+		}
+	}
+	//insertion sort, there are never more than 13
+	for i := 1; i < count; i++ {
+		for j := i; j > 0 && scores[j] > scores[j-1]; j-- {
+			scores[j], scores[j-1] = scores[j-1], scores[j]
+			moves[j], moves[j-1] = moves[j-1], moves[j]
+		}
+	}
+	return count
+}
+
+// A rough guess at how good a lead is: cash a winner, put partner in, give
+// partner a ruff, or else lead low from length.
+func (s *solver) leadScore(player int, c int) int {
+	suit, rank := suitOf(c), c%16
+	partner := (player + 2) % 4
+	left, right := s.hands[(player+1)%4], s.hands[(player+3)%4]
+	cards := s.all.suit(suit)
+	ruffed := s.trump != NoTrump && suit != s.trump && (left|right).suit(s.trump) != 0 &&
+		(left.suit(suit) == 0 || right.suit(suit) == 0)
+	top := bits.Len64(cards) - 1
+	switch {
+		case cards>>(rank+1) == 0 && !ruffed:
+			return 100 + rank
+		case s.hands[partner].suit(suit)>>top&1 != 0 && !ruffed:
+			return 80 - rank
+		case s.trump != NoTrump && suit != s.trump && s.hands[partner].suit(suit) == 0 &&
+			s.hands[partner].suit(s.trump) != 0:
+			return 60 - rank
+	}
+	return 2*bits.OnesCount64(s.hands[player].suit(suit)) - rank
+}
+
+// A rough guess at how good a card is to follow with: leave a trick partner
+// has won, win one as cheaply as can be kept, and otherwise play low.
+func (s *solver) followScore(t *trick, pos int, player int, c int, winning int, partnerWinning bool) int {
+	rank := c % 16
+	led := suitOf(t.cards[0])
+	penalty := 0
+	if suitOf(c) == s.trump && led != s.trump {
+		penalty = 50
+	}
+	if partnerWinning || !s.beats(c, winning) {
+		return -rank - penalty
+	}
+	if pos == 3 || !s.canBeat((player+1)%4, led, c) {
+		return 60 - rank
+	}
+	if pos == 2 {
+		return 30 - rank
+	}
+	//second hand low
+	return -20 - rank
+}
+
+// TrickTable holds the tricks each declarer takes in each strain, indexed
+// by seat and then strain.
+type TrickTable [4][5]int
+
+// Solve works out the trick table of a deal. Each strain is searched on its
+// own, side by side.
+func Solve(deal Deal) TrickTable {
+	var table TrickTable
+	total := bits.OnesCount64(uint64(deal[North]))
+	var wg sync.WaitGroup
+	for strain := Spades; strain <= NoTrump; strain++ {
+		wg.Add(1)
+		go func(strain int) {
+			defer wg.Done()
+			s := newSolver(deal, strain)
+			guess := total / 2
+			for declarer := North; declarer <= West; declarer++ {
+				//the opening lead comes from declarer's left
+				northSouth := s.tricks((declarer+1)%4, guess)
+				guess = northSouth
+				if declarer%2 == North {
+					table[declarer][strain] = northSouth
+				} else {
+					table[declarer][strain] = total - northSouth
+				}
+			}
+		}(strain)
+	}
+	wg.Wait()
+	return table
+}
@@ -0,0 +1,164 @@
+package dds
+
+import (
+	"math/bits"
+	"math/rand"
+	"src/util/boards"
+	"testing"
+)
+
+func mustParse(t *testing.T, hands [4]string) Deal {
+	t.Helper()
+	deal, err := ParseDeal(hands)
+	if err != nil {
+		t.Fatalf("ParseDeal(%q): %v", hands, err)
+	}
+	return deal
+}
+
+func TestParseDeal(t *testing.T) {
+	for _, hands := range [][4]string{
+		{"AKQJ.AKQ.AKQ.AKQ", "T987.JT9.JT9.JT9", "6543.876.876.876", ""},
+		{"A.K..", "Q...", "J...", "T..."},
+		{"A...", "A...", "K...", "Q..."},
+		{"A...", "K...", "Q...", "X..."},
+	} {
+		if _, err := ParseDeal(hands); err == nil {
+			t.Errorf("ParseDeal(%q) took a bad deal", hands)
+		}
+	}
+}
+
+// The tricks North-South take with leader on lead, trying every card
+// there is, for checking the solver on small endings.
+func bruteForce(deal Deal, strain int, leader int) int {
+	if deal[leader] == 0 {
+		return 0
+	}
+	var search func(deal Deal, player int, led int, winner int, winning int) int
+	search = func(deal Deal, player int, led int, winner int, winning int) int {
+		if player == leader && led >= 0 {
+			tricks := bruteForce(deal, strain, winner)
+			if winner%2 == North {
+				tricks++
+			}
+			return tricks
+		}
+		cards := deal[player]
+		if led >= 0 && deal[player].suit(led) != 0 {
+			cards = hand(deal[player].suit(led)) << (led * 16)
+		}
+		best := -1
+		for c := 0; c < 64; c++ {
+			if cards&(1<<c) == 0 {
+				continue
+			}
+			next := deal
+			next[player] &^= 1 << c
+			var tricks int
+			switch {
+				case led < 0:
+					tricks = search(next, (player+1)%4, suitOf(c), player, c)
+				case suitOf(c) == suitOf(winning) && c > winning,
+					suitOf(c) == strain && suitOf(winning) != strain:
+					tricks = search(next, (player+1)%4, led, player, c)
+				default:
+					tricks = search(next, (player+1)%4, led, winner, winning)
+			}
+			if best < 0 || (player%2 == North) == (tricks > best) {
+				best = tricks
+			}
+		}
+		return best
+	}
+	return search(deal, leader, -1, leader, 0)
+}
+
+func TestSolveEnding(t *testing.T) {
+	//a heart lead beats notrump from North, a spade lead does not from South,
+	//and the lone ace of hearts makes as trumps from either side
+	deal := mustParse(t, [4]string{"A...", ".A..", "K...", "Q..."})
+	want := TrickTable{
+		{1, 0, 0, 0, 0},
+		{0, 1, 0, 0, 0},
+		{1, 0, 1, 1, 1},
+		{0, 1, 0, 0, 0},
+	}
+	if got := Solve(deal); got != want {
+		t.Errorf("Solve = %v, want %v", got, want)
+	}
+}
+
+func TestSolveMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 40; n++ {
+		size := 1 + n%4
+		cards := r.Perm(52)[:4*size]
+		var deal Deal
+		for i, c := range cards {
+			deal[i%4] |= 1 << card(c/13, c%13+2)
+		}
+
+		got := Solve(deal)
+		total := bits.OnesCount64(uint64(deal[North]))
+		for strain := Spades; strain <= NoTrump; strain++ {
+			for declarer := North; declarer <= West; declarer++ {
+				want := bruteForce(deal, strain, (declarer+1)%4)
+				if declarer%2 == East {
+					want = total - want
+				}
+				if got[declarer][strain] != want {
+					t.Fatalf("deal %v: declarer %d strain %d takes %d, want %d", deal, declarer, strain, got[declarer][strain], want)
+				}
+			}
+		}
+	}
+}
+
+// Deals with their trick tables and par at each vulnerability, dealt by
+// North. A deal whose tricks depend on long play takes seconds to solve.
+var fullDeals = []struct {
+	name  string
+	hands [4]string
+	slow  bool
+	want  TrickTable
+	par   [4]Par
+}{
+	{
+		name:  "grand slam",
+		hands: [4]string{"AKQJ.AKQ.AKQ.AKQ", "T987.JT9.JT9.JT9", "6543.876.876.876", "2.5432.5432.5432"},
+		want:  TrickTable{{13, 12, 12, 12, 13}, {0, 1, 1, 1, 0}, {13, 12, 12, 12, 13}, {0, 1, 1, 1, 0}},
+		par:   [4]Par{{1520, "7NT by NS"}, {2220, "7NT by NS"}, {1520, "7NT by NS"}, {2220, "7NT by NS"}},
+	},
+	{
+		//both sides make eight tricks in their own minor, diamonds win
+		name:  "part score both ways",
+		hands: [4]string{"9.Q987.QT6.KQJ97", "A63.AJ632.A95.83", "QJT854.K5.72.A54", "K72.T4.KJ843.T62"},
+		slow:  true,
+		want:  TrickTable{{7, 5, 4, 8, 6}, {5, 7, 8, 5, 6}, {7, 5, 4, 8, 6}, {6, 7, 8, 5, 6}},
+		par:   [4]Par{{-90, "2D by EW"}, {-90, "2D by EW"}, {-90, "2D by EW"}, {-90, "2D by EW"}},
+	},
+}
+
+func TestSolveFullDeal(t *testing.T) {
+	for _, test := range fullDeals {
+		t.Run(test.name, func(t *testing.T) {
+			if test.slow && testing.Short() {
+				t.Skip("solving this deal takes seconds")
+			}
+			table := Solve(mustParse(t, test.hands))
+			if table != test.want {
+				t.Fatalf("Solve = %v, want %v", table, test.want)
+			}
+			for vulnerable := boards.VulNone; vulnerable <= boards.VulAll; vulnerable++ {
+				got, err := CalculatePar(table, vulnerable, "N")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != test.par[vulnerable] {
+					t.Errorf("vulnerability %d: CalculatePar = %+v, want %+v", vulnerable, got, test.par[vulnerable])
+				}
+			}
+		})
+	}
+}
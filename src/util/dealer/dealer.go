@@ -0,0 +1,255 @@
+package dealer
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"slices"
+	"src/types"
+	"src/util/boards"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidSeat  = errors.New("seat must be N, E, S or W")
+	ErrInvalidHCP   = errors.New("high card points must be between 0 and 37, the minimum no more than the maximum")
+	ErrInvalidShape = errors.New("shape must be balanced, or four lengths in spades, hearts, diamonds, clubs order like 5xxx or 4432, with an any prefix for any suit order")
+	ErrImpossible   = errors.New("no deal fits the constraints")
+)
+
+// How many deals are tried for a board before its constraints are given up on.
+const maxAttempts = 1000000
+
+const ranks = "AKQJT98765432"
+
+var seats = []string{"N", "E", "S", "W"}
+
+// Hand limits what one seat is dealt. Zero values leave it unlimited.
+type Hand struct {
+	MinHCP int
+	MaxHCP int      //0 for no maximum
+	Shapes []string //e.g. balanced, 5xxx, any 4441; the hand has to fit one of them
+}
+
+// Constraints are keyed by seat, N, E, S or W. Seats left out are dealt anything.
+type Constraints map[string]Hand
+
+// A shape read from a pattern: a length or -1 for each suit, and whether
+// the suits may come in any order.
+type shape struct {
+	lengths  [4]int
+	anyOrder bool
+}
+
+var balanced = []shape{
+	{lengths: [4]int{4, 3, 3, 3}, anyOrder: true},
+	{lengths: [4]int{4, 4, 3, 2}, anyOrder: true},
+	{lengths: [4]int{5, 3, 3, 2}, anyOrder: true},
+}
+
+func parseShape(pattern string) ([]shape, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "balanced" {
+		return balanced, nil
+	}
+	var s shape
+	if rest, ok := strings.CutPrefix(pattern, "any"); ok {
+		s.anyOrder = true
+		pattern = strings.TrimSpace(rest)
+	}
+	pattern = strings.ReplaceAll(pattern, "-", "")
+	if len(pattern) != 4 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidShape, pattern)
+	}
+	total, open := 0, false
+	for i, length := range pattern {
+		switch {
+			case length == 'x':
+				s.lengths[i], open = -1, true
+			case length >= '0' && length <= '9':
+				s.lengths[i] = int(length - '0')
+				total += s.lengths[i]
+			default:
+				return nil, fmt.Errorf("%w: %q", ErrInvalidShape, pattern)
+		}
+	}
+	if total > 13 || (!open && total != 13) {
+		return nil, fmt.Errorf("%w: %q adds up to %d cards", ErrInvalidShape, pattern, total)
+	}
+	return []shape{s}, nil
+}
+
+func (s shape) fits(lengths [4]int) bool {
+	if !s.anyOrder {
+		return matches(s.lengths, lengths)
+	}
+	//suits of the same length are interchangeable, so each fixed length takes the first unused suit as long
+	var used [4]bool
+	for _, want := range s.lengths {
+		if want < 0 {
+			continue
+		}
+		found := false
+		for i, have := range lengths {
+			if !used[i] && have == want {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func matches(want [4]int, have [4]int) bool {
+	for i := range want {
+		if want[i] >= 0 && want[i] != have[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// A seat's limits checked and its shapes read.
+type limits struct {
+	minHCP int
+	maxHCP int
+	shapes []shape
+}
+
+func (l limits) fits(cards []int) bool {
+	hcp := 0
+	var lengths [4]int
+	for _, card := range cards {
+		if rank := card % 13; rank < 4 {
+			hcp += 4 - rank
+		}
+		lengths[card/13]++
+	}
+	if hcp < l.minHCP || hcp > l.maxHCP {
+		return false
+	}
+	if len(l.shapes) == 0 {
+		return true
+	}
+	for _, s := range l.shapes {
+		if s.fits(lengths) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseConstraints(constraints Constraints) ([4]*limits, error) {
+	var all [4]*limits
+	for seat, hand := range constraints {
+		i := slices.Index(seats, strings.ToUpper(seat))
+		if i < 0 {
+			return all, fmt.Errorf("%w: %q", ErrInvalidSeat, seat)
+		}
+		l := &limits{minHCP: hand.MinHCP, maxHCP: hand.MaxHCP}
+		if l.maxHCP == 0 {
+			l.maxHCP = 37
+		}
+		if l.minHCP < 0 || l.maxHCP > 37 || l.minHCP > l.maxHCP {
+			return all, fmt.Errorf("%s: %w", seats[i], ErrInvalidHCP)
+		}
+		for _, pattern := range hand.Shapes {
+			shapes, err := parseShape(pattern)
+			if err != nil {
+				return all, fmt.Errorf("%s: %w", seats[i], err)
+			}
+			l.shapes = append(l.shapes, shapes...)
+		}
+		all[i] = l
+	}
+	return all, nil
+}
+
+// Seed turns a tournament id and board number into the seed that board is
+// dealt from, so every board has its own run of shuffles.
+func Seed(tournamentId string, boardNumber int) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(tournamentId + "/" + strconv.Itoa(boardNumber)))
+	return int64(hash.Sum64())
+}
+
+// Generate deals the given boards for a tournament. The same id and
+// constraints always deal the same hands, so a session can be redealt.
+func Generate(tournamentId string, boardNumbers []int, constraints Constraints) ([]types.Deal, error) {
+	all, err := parseConstraints(constraints)
+	if err != nil {
+		return nil, err
+	}
+	var deals []types.Deal
+	for _, boardNumber := range boardNumbers {
+		deal, err := generate(boardNumber, rand.New(rand.NewSource(Seed(tournamentId, boardNumber))), all)
+		if err != nil {
+			return nil, err
+		}
+		deal.TournamentId = tournamentId
+		deals = append(deals, deal)
+	}
+	return deals, nil
+}
+
+func generate(boardNumber int, r *rand.Rand, all [4]*limits) (types.Deal, error) {
+	info, err := boards.Get(boardNumber)
+	if err != nil {
+		return types.Deal{}, err
+	}
+	deal := types.Deal{BoardNumber: boardNumber, Dealer: info.Dealer, Vulnerability: info.VulName}
+
+	//cards are numbered spades down to clubs, ace down to two within a suit
+	cards := make([]int, 52)
+	for i := range cards {
+		cards[i] = i
+	}
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		r.Shuffle(len(cards), func(i int, j int) { cards[i], cards[j] = cards[j], cards[i] })
+		fits := true
+		for seat, l := range all {
+			if l != nil && !l.fits(cards[seat*13:seat*13+13]) {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			deal.North, deal.East, deal.South, deal.West = format(cards[:13]), format(cards[13:26]), format(cards[26:39]), format(cards[39:])
+			return deal, nil
+		}
+	}
+	return deal, fmt.Errorf("board %d: %w", boardNumber, ErrImpossible)
+}
+
+// Writes a hand the way deals are stored, spades.hearts.diamonds.clubs.
+func format(cards []int) string {
+	sorted := slices.Sorted(slices.Values(cards))
+	var suits [4]strings.Builder
+	for _, card := range sorted {
+		suits[card/13].WriteByte(ranks[card%13])
+	}
+	return suits[0].String() + "." + suits[1].String() + "." + suits[2].String() + "." + suits[3].String()
+}
+
+// HCP counts the high card points of a stored hand, ace 4 down to jack 1.
+func HCP(hand string) int {
+	hcp := 0
+	for _, card := range hand {
+		switch card {
+			case 'A':
+				hcp += 4
+			case 'K':
+				hcp += 3
+			case 'Q':
+				hcp += 2
+			case 'J':
+				hcp++
+		}
+	}
+	return hcp
+}
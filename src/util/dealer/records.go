@@ -0,0 +1,66 @@
+package dealer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"src/types"
+	"strings"
+)
+
+// Wide enough for a thirteen card suit after its letter.
+const column = 18
+
+var suitLetters = []string{"S", "H", "D", "C"}
+
+// The four suit lines of a hand, a dash for a void.
+func suitLines(hand string) []string {
+	suits := strings.Split(hand, ".")
+	lines := make([]string, 4)
+	for i := range lines {
+		cards := "-"
+		if i < len(suits) && suits[i] != "" {
+			cards = suits[i]
+		}
+		lines[i] = suitLetters[i] + " " + cards
+	}
+	return lines
+}
+
+// WriteHandRecords prints each board's hands as they sit round the table,
+// with the high card points and, once a board has been solved, the tricks
+// each seat makes double dummy and par.
+func WriteHandRecords(w io.Writer, event string, deals []types.Deal) error {
+	out := bufio.NewWriter(w)
+	if event != "" {
+		fmt.Fprintf(out, "%s\n\n", event)
+	}
+	for _, deal := range deals {
+		north, east, south, west := suitLines(deal.North), suitLines(deal.East), suitLines(deal.South), suitLines(deal.West)
+		side := []string{fmt.Sprintf("Board %d", deal.BoardNumber), "Dealer " + deal.Dealer, "Vul " + deal.Vulnerability, ""}
+		for i := range north {
+			fmt.Fprintf(out, "%-*s%s\n", column, side[i], north[i])
+		}
+		for i := range west {
+			fmt.Fprintf(out, "%-*s%s\n", 2*column, west[i], east[i])
+		}
+		for i := range south {
+			fmt.Fprintf(out, "%-*s%s\n", column, "", south[i])
+		}
+		fmt.Fprintf(out, "HCP N %d, E %d, S %d, W %d\n", HCP(deal.North), HCP(deal.East), HCP(deal.South), HCP(deal.West))
+
+		if analysis := deal.Analysis; analysis != nil {
+			fmt.Fprintf(out, "\n %3s%3s%3s%3s%3s\n", "S", "H", "D", "C", "NT")
+			for seat, tricks := range analysis.Tricks {
+				fmt.Fprint(out, seats[seat])
+				for _, made := range tricks {
+					fmt.Fprintf(out, "%3d", made)
+				}
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "Par %s, NS %d\n", analysis.ParContract, analysis.ParScore)
+		}
+		fmt.Fprintln(out)
+	}
+	return out.Flush()
+}
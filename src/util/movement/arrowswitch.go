@@ -0,0 +1,34 @@
+package movement
+
+import (
+	"fmt"
+)
+
+// ArrowSwitch turns the NS and EW pairs round at every table in the given
+// rounds, so in a Mitchell every pair plays both directions and the field
+// can be ranked as one.
+type ArrowSwitch struct {
+	Movement Movement
+	Rounds   []int
+}
+
+func (m ArrowSwitch) Pairs() []string {
+	return m.Movement.Pairs()
+}
+
+func (m ArrowSwitch) Schedule() (*Schedule, error) {
+	schedule, err := m.Movement.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	for _, round := range m.Rounds {
+		if round < 1 || round > len(schedule.Rounds) {
+			return nil, fmt.Errorf("cannot switch arrows in round %d of %d", round, len(schedule.Rounds))
+		}
+		for i := range schedule.Rounds[round-1] {
+			seat := &schedule.Rounds[round-1][i]
+			seat.NSPair, seat.EWPair = seat.EWPair, seat.NSPair
+		}
+	}
+	return schedule, nil
+}
@@ -0,0 +1,141 @@
+package movement
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Howell seats 2*Tables pairs so that every pair meets every other pair once
+// over 2*Tables-1 rounds, switching direction between rounds wherever the
+// round robin allows. Pair ids are "1" to "2*Tables". There are 2*Tables-1
+// board sets and each table moves on one set a round from its own starting
+// set, chosen so no pair meets a set twice and one copy of the boards will
+// do. Where no such start exists the fewest tables share a set, each
+// starting part way through it so they can relay the boards; with 3 tables
+// all of them share. With SitOut the stationary pair is missing and whoever
+// it would have met sits out.
+type Howell struct {
+	Tables         int
+	BoardsPerRound int
+	Rounds         int
+	SitOut         bool
+}
+
+func (m Howell) Pairs() []string {
+	return withoutPhantom(m.allPairs(), m.SitOut)
+}
+
+func (m Howell) allPairs() []string {
+	var pairs []string
+	for n := 1; n <= 2*m.Tables; n++ {
+		pairs = append(pairs, strconv.Itoa(n))
+	}
+	return pairs
+}
+
+func (m Howell) Schedule() (*Schedule, error) {
+	if m.Tables < 2 {
+		return nil, fmt.Errorf("a howell needs at least 2 tables, got %d", m.Tables)
+	}
+	cycle := 2*m.Tables - 1
+	if m.Rounds < 1 || m.Rounds > cycle {
+		return nil, fmt.Errorf("a %d table howell has between 1 and %d rounds, got %d", m.Tables, cycle, m.Rounds)
+	}
+
+	fixed := strconv.Itoa(2 * m.Tables)
+	starts := howellStarts(m.Tables)
+	schedule := &Schedule{}
+	for round := 1; round <= m.Rounds; round++ {
+		seats := make([]Seat, m.Tables)
+		boards := make([][]int, m.Tables)
+		sharing := make(map[int]int)
+		for _, start := range starts {
+			sharing[(round-1+start)%cycle]++
+		}
+		relayed := make(map[int]int)
+		for table, start := range starts {
+			set := (round - 1 + start) % cycle
+			boards[table] = boardSet(set, m.BoardsPerRound)
+			// Tables sharing a set start at different boards of it
+			turn := relayed[set] * m.BoardsPerRound / sharing[set]
+			boards[table] = append(boards[table][turn:], boards[table][:turn]...)
+			relayed[set]++
+		}
+
+		// The stationary pair meets whichever pair has rotated onto it
+		mover := wrap(round, cycle)
+		if round%2 == 1 {
+			seats[0] = Seat{Table: 1, NSPair: fixed, EWPair: strconv.Itoa(mover), Boards: boards[0]}
+		} else {
+			seats[0] = Seat{Table: 1, NSPair: strconv.Itoa(mover), EWPair: fixed, Boards: boards[0]}
+		}
+
+		// The rest pair up by their offset from the mover, the pair with
+		// the odd offset sitting NS
+		for offset := 1; offset < m.Tables; offset++ {
+			a := wrap(mover+offset, cycle)
+			b := wrap(mover-offset, cycle)
+			if offset%2 == 0 {
+				a, b = b, a
+			}
+			seats[offset] = Seat{Table: offset + 1, NSPair: strconv.Itoa(a), EWPair: strconv.Itoa(b), Boards: boards[offset]}
+		}
+		schedule.Rounds = append(schedule.Rounds, seats)
+	}
+	if m.SitOut {
+		return withSitOut(m.allPairs(), schedule), nil
+	}
+	return schedule, nil
+}
+
+var (
+	startsMu       sync.Mutex
+	startsByTables = make(map[int][]int)
+)
+
+// The set each table starts on, the table at offset k from the mover
+// seating pairs k either side of it. A pair a set's offset minus or plus k
+// from the mover meets it there, so every offset and table has to reach a
+// different set relative to the pair for no pair to meet a set twice. Each
+// set is first kept to one table, then let be shared by more.
+func howellStarts(tables int) []int {
+	startsMu.Lock()
+	defer startsMu.Unlock()
+	if starts, ok := startsByTables[tables]; ok {
+		return starts
+	}
+
+	cycle := 2*tables - 1
+	starts := make([]int, tables)
+	for share := 1; share <= tables; share++ {
+		met := make([]bool, cycle)
+		uses := make([]int, cycle)
+		var place func(offset int) bool
+		place = func(offset int) bool {
+			if offset == tables {
+				return true
+			}
+			for set := 0; set < cycle; set++ {
+				a, b := wrap(set-offset+1, cycle)-1, wrap(set+offset+1, cycle)-1
+				if uses[set] == share || met[a] || met[b] || (offset > 0 && a == b) {
+					continue
+				}
+				starts[offset] = set
+				uses[set]++
+				met[a], met[b] = true, true
+				if place(offset + 1) {
+					return true
+				}
+				uses[set]--
+				met[a], met[b] = false, false
+			}
+			return false
+		}
+		if place(0) {
+			break
+		}
+	}
+	startsByTables[tables] = starts
+	return starts
+}
@@ -0,0 +1,135 @@
+package movement
+
+import (
+	"fmt"
+)
+
+// Mitchell keeps NS pair n at table n while the EW pairs move up one table
+// and the boards move down one table each round. Pair ids are "<n>NS" and
+// "<n>EW". With an even number of tables the EW pairs would meet boards they
+// have already played halfway through, so Skip moves them up an extra table
+// after Tables/2 rounds. With SitOut the last EW pair is missing and the
+// NS pair it would have met sits out.
+type Mitchell struct {
+	Tables         int
+	BoardsPerRound int
+	Rounds         int
+	Skip           bool
+	SitOut         bool
+}
+
+func (m Mitchell) Pairs() []string {
+	return withoutPhantom(mitchellPairs(m.Tables), m.SitOut)
+}
+
+func (m Mitchell) Schedule() (*Schedule, error) {
+	if m.Tables < 1 {
+		return nil, fmt.Errorf("a mitchell needs at least 1 table, got %d", m.Tables)
+	}
+	maxRounds := m.Tables
+	if m.Skip {
+		if m.Tables%2 != 0 {
+			return nil, fmt.Errorf("a skip mitchell needs an even number of tables, got %d", m.Tables)
+		}
+		maxRounds = m.Tables - 1
+	} else if m.Tables%2 == 0 {
+		maxRounds = m.Tables / 2
+	}
+	if m.Rounds < 1 || m.Rounds > maxRounds {
+		return nil, fmt.Errorf("a %d table mitchell has between 1 and %d rounds, got %d", m.Tables, maxRounds, m.Rounds)
+	}
+
+	schedule := &Schedule{}
+	for round := 1; round <= m.Rounds; round++ {
+		shift := round - 1
+		if m.Skip && round > m.Tables/2 {
+			shift++
+		}
+		var seats []Seat
+		for table := 1; table <= m.Tables; table++ {
+			set := (table - 1 + round - 1) % m.Tables
+			seats = append(seats, Seat{
+				Table:  table,
+				NSPair: fmt.Sprintf("%dNS", table),
+				EWPair: fmt.Sprintf("%dEW", wrap(table-shift, m.Tables)),
+				Boards: boardSet(set, m.BoardsPerRound),
+			})
+		}
+		schedule.Rounds = append(schedule.Rounds, seats)
+	}
+	if m.SitOut {
+		return withSitOut(mitchellPairs(m.Tables), schedule), nil
+	}
+	return schedule, nil
+}
+
+// RelayMitchell is the relay and bye-stand Mitchell for an even number of
+// tables. A bye-stand between the middle tables holds one board set each
+// round, and tables 1 and Tables share a set, so the EW pairs meet every set
+// without skipping. The last table plays the shared set in the opposite
+// order so both tables can relay the boards. SitOut works as in Mitchell.
+type RelayMitchell struct {
+	Tables         int
+	BoardsPerRound int
+	Rounds         int
+	SitOut         bool
+}
+
+func (m RelayMitchell) Pairs() []string {
+	return withoutPhantom(mitchellPairs(m.Tables), m.SitOut)
+}
+
+func (m RelayMitchell) Schedule() (*Schedule, error) {
+	if m.Tables < 4 || m.Tables%2 != 0 {
+		return nil, fmt.Errorf("a relay mitchell needs an even number of tables from 4 up, got %d", m.Tables)
+	}
+	if m.Rounds < 1 || m.Rounds > m.Tables {
+		return nil, fmt.Errorf("a %d table relay mitchell has between 1 and %d rounds, got %d", m.Tables, m.Tables, m.Rounds)
+	}
+
+	// Position of each table in the board circuit. The boards move down from
+	// the shared tables through the top half, the bye-stand and the bottom
+	// half back to the shared tables.
+	position := func(table int) int {
+		switch {
+		case table == 1 || table == m.Tables:
+			return 0
+		case table > m.Tables/2:
+			return m.Tables - table
+		default:
+			return m.Tables - table + 1
+		}
+	}
+
+	schedule := &Schedule{}
+	for round := 1; round <= m.Rounds; round++ {
+		var seats []Seat
+		for table := 1; table <= m.Tables; table++ {
+			set := ((position(table)-(round-1))%m.Tables + m.Tables) % m.Tables
+			boards := boardSet(set, m.BoardsPerRound)
+			if table == m.Tables {
+				half := len(boards) / 2
+				boards = append(boards[half:], boards[:half]...)
+			}
+			seats = append(seats, Seat{
+				Table:  table,
+				NSPair: fmt.Sprintf("%dNS", table),
+				EWPair: fmt.Sprintf("%dEW", wrap(table-(round-1), m.Tables)),
+				Boards: boards,
+			})
+		}
+		schedule.Rounds = append(schedule.Rounds, seats)
+	}
+	if m.SitOut {
+		return withSitOut(mitchellPairs(m.Tables), schedule), nil
+	}
+	return schedule, nil
+}
+
+func mitchellPairs(tables int) []string {
+	var pairs []string
+	for table := 1; table <= tables; table++ {
+		pairs = append(pairs, fmt.Sprintf("%dNS", table), fmt.Sprintf("%dEW", table))
+	}
+	return pairs
+}
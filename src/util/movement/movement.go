@@ -0,0 +1,127 @@
+package movement
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Movement seats the field for a whole session.
+type Movement interface {
+	// Pairs lists the pair ids in the order pairs are registered
+	Pairs() []string
+	Schedule() (*Schedule, error)
+}
+
+// SitOut stands in for the missing pair when the field is odd. A pair drawn
+// against it sits the round out.
+const SitOut = "SitOut"
+
+// Seat is one table in one round of a movement.
+type Seat struct {
+	Table  int
+	Room   string //only set for team matches
+	NSPair string
+	EWPair string
+	Boards []int
+}
+
+// Schedule holds the seating of every table, round by round.
+type Schedule struct {
+	Rounds [][]Seat //Rounds[0] is round 1
+}
+
+// FindSeat returns the seat of a pair in the given round.
+func (s *Schedule) FindSeat(round int, pairId string) (*Seat, error) {
+	if round < 1 || round > len(s.Rounds) {
+		return nil, fmt.Errorf("round %d is not in the movement", round)
+	}
+	for _, seat := range s.Rounds[round-1] {
+		if seat.NSPair == pairId || seat.EWPair == pairId {
+			return &seat, nil
+		}
+	}
+	return nil, fmt.Errorf("pair %s is not seated in round %d", pairId, round)
+}
+
+// BoardCopies is how many copies of each board the club needs. Tables
+// playing the same set in a round relay its boards, so a second copy is only
+// needed when more tables share a set than it has boards.
+func (s *Schedule) BoardCopies() int {
+	copies := 1
+	for _, seats := range s.Rounds {
+		sharing := make(map[int]int)
+		size := make(map[int]int)
+		for _, seat := range seats {
+			if seat.IsBye() || len(seat.Boards) == 0 {
+				continue
+			}
+			first := slices.Min(seat.Boards)
+			sharing[first]++
+			size[first] = len(seat.Boards)
+		}
+		for first, tables := range sharing {
+			copies = max(copies, (tables+size[first]-1)/size[first])
+		}
+	}
+	return copies
+}
+
+// IsBye reports whether the seat is a sit-out rather than a real table.
+func (s Seat) IsBye() bool {
+	return s.NSPair == SitOut || s.EWPair == SitOut
+}
+
+// Direction returns "NS" or "EW" for the pair at the seat.
+func (s Seat) Direction(pairId string) string {
+	if s.NSPair == pairId {
+		return "NS"
+	}
+	return "EW"
+}
+
+// Opponent returns the pair sitting across from the given pair.
+func (s Seat) Opponent(pairId string) string {
+	if s.NSPair == pairId {
+		return s.EWPair
+	}
+	return s.NSPair
+}
+
+// Drops the last pair of a full field when it is missing and puts SitOut in
+// its place in the schedule.
+func withSitOut(pairs []string, schedule *Schedule) *Schedule {
+	phantom := pairs[len(pairs)-1]
+	for _, seats := range schedule.Rounds {
+		for i := range seats {
+			if seats[i].NSPair == phantom {
+				seats[i].NSPair = SitOut
+			}
+			if seats[i].EWPair == phantom {
+				seats[i].EWPair = SitOut
+			}
+		}
+	}
+	return schedule
+}
+
+func withoutPhantom(pairs []string, sitOut bool) []string {
+	if sitOut {
+		return pairs[:len(pairs)-1]
+	}
+	return pairs
+}
+
+// Boards of a set, sets counted from 0.
+func boardSet(set int, boardsPerRound int) []int {
+	boards := make([]int, boardsPerRound)
+	for i := range boards {
+		boards[i] = set*boardsPerRound + i + 1
+	}
+	return boards
+}
+
+// Wraps n into 1..cycle.
+func wrap(n int, cycle int) int {
+	n = ((n-1)%cycle + cycle) % cycle
+	return n + 1
+}
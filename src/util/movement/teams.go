@@ -0,0 +1,70 @@
+package movement
+
+import (
+	"fmt"
+)
+
+const (
+	OpenRoom   = "Open"
+	ClosedRoom = "Closed"
+)
+
+// TeamRoundRobin plays a round robin of team matches. Team n has pairs "<n>NS"
+// and "<n>EW". In each match the home team's NS pair meets the away team's
+// EW pair in the open room, the away NS pair meets the home EW pair in the
+// closed room, and both rooms play the same boards.
+type TeamRoundRobin struct {
+	Teams          int
+	BoardsPerRound int
+	Rounds         int
+}
+
+func (m TeamRoundRobin) Pairs() []string {
+	return mitchellPairs(m.Teams)
+}
+
+func (m TeamRoundRobin) Schedule() (*Schedule, error) {
+	if m.Teams < 2 || m.Teams%2 != 0 {
+		return nil, fmt.Errorf("a team round robin needs an even number of teams, got %d", m.Teams)
+	}
+	if m.Rounds < 1 || m.Rounds > m.Teams-1 {
+		return nil, fmt.Errorf("%d teams play between 1 and %d rounds, got %d", m.Teams, m.Teams-1, m.Rounds)
+	}
+
+	schedule := &Schedule{}
+	for round := 1; round <= m.Rounds; round++ {
+		boards := boardSet(round-1, m.BoardsPerRound)
+		var seats []Seat
+		match := 0
+		for team := 1; team <= m.Teams; team++ {
+			opp, home := teamOpponent(team, round, m.Teams)
+			if !home {
+				continue
+			}
+			match++
+			seats = append(seats,
+				Seat{Table: match, Room: OpenRoom, NSPair: fmt.Sprintf("%dNS", team), EWPair: fmt.Sprintf("%dEW", opp), Boards: boards},
+				Seat{Table: match, Room: ClosedRoom, NSPair: fmt.Sprintf("%dNS", opp), EWPair: fmt.Sprintf("%dEW", team), Boards: boards},
+			)
+		}
+		schedule.Rounds = append(schedule.Rounds, seats)
+	}
+	return schedule, nil
+}
+
+// Round robin by the circle method: the last team stays put while the others
+// rotate. Returns the opposing team and whether this team is at home.
+func teamOpponent(team int, round int, totalTeams int) (int, bool) {
+	fixed := totalTeams
+	cycle := totalTeams - 1
+	mover := wrap(round, cycle)
+	if team == fixed {
+		return mover, round%2 == 1
+	}
+	if team == mover {
+		return fixed, round%2 == 0
+	}
+
+	offset := ((team-mover)%cycle + cycle) % cycle
+	return wrap(mover-offset, cycle), offset <= (cycle-1)/2
+}
@@ -0,0 +1,317 @@
+package pbn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"src/types"
+	"src/util/scoring"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoBoard           = errors.New("deal has no board number")
+	ErrInvalidDeal       = errors.New("deal must be a seat followed by four hands, e.g. N:AKQ.JT9.876.5432 ...")
+	ErrInvalidHand       = errors.New("hand must be four suits of spades, hearts, diamonds and clubs")
+	ErrInvalidCard       = errors.New("card must be A, K, Q, J, T or 9 down to 2")
+	ErrIncompleteDeal    = errors.New("every hand must hold 13 cards and every card be dealt once")
+	ErrInvalidDealer     = errors.New("dealer must be N, E, S or W")
+	ErrInvalidVulnerable = errors.New("vulnerable must be None, NS, EW or All")
+)
+
+// Seats in the order PBN deals them, clockwise from North.
+var Seats = []string{"N", "E", "S", "W"}
+
+const ranks = "AKQJT98765432"
+
+var tagPattern = regexp.MustCompile(`^\[(\w+)\s+"(.*)"\]`)
+
+// Parse reads the deals out of a PBN file. Games without a Deal tag, like
+// the header many dealing programs write, are skipped.
+func Parse(r io.Reader) ([]types.Deal, error) {
+	var deals []types.Deal
+	tags := make(map[string]string)
+
+	flush := func() error {
+		defer func() { tags = make(map[string]string) }()
+		if _, ok := tags["Deal"]; !ok {
+			return nil
+		}
+		deal, err := parseGame(tags)
+		if err != nil {
+			return err
+		}
+		deals = append(deals, deal)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	inComment := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		//commentary can run over several lines
+		if inComment {
+			inComment = !strings.Contains(line, "}")
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			inComment = !strings.Contains(line, "}")
+			continue
+		}
+		if line == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if match := tagPattern.FindStringSubmatch(line); match != nil {
+			//a second board starts the next game when there is no blank line between them
+			if _, ok := tags["Board"]; ok && match[1] == "Board" {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			tags[match[1]] = match[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return deals, nil
+}
+
+func parseGame(tags map[string]string) (types.Deal, error) {
+	var deal types.Deal
+	boardNumber, err := strconv.Atoi(strings.TrimSpace(tags["Board"]))
+	if err != nil || boardNumber < 1 {
+		return deal, fmt.Errorf("%w: %q", ErrNoBoard, tags["Board"])
+	}
+	deal.BoardNumber = boardNumber
+
+	deal.Dealer = strings.ToUpper(strings.TrimSpace(tags["Dealer"]))
+	if strings.Index("NESW", deal.Dealer) < 0 || len(deal.Dealer) != 1 {
+		return deal, fmt.Errorf("board %d: %w: %q", boardNumber, ErrInvalidDealer, tags["Dealer"])
+	}
+	deal.Vulnerability, err = ParseVulnerable(tags["Vulnerable"])
+	if err != nil {
+		return deal, fmt.Errorf("board %d: %w", boardNumber, err)
+	}
+
+	hands, err := ParseDeal(tags["Deal"])
+	if err != nil {
+		return deal, fmt.Errorf("board %d: %w", boardNumber, err)
+	}
+	deal.North, deal.East, deal.South, deal.West = hands[0], hands[1], hands[2], hands[3]
+	return deal, nil
+}
+
+// ParseVulnerable accepts the spellings PBN allows and returns None, NS, EW or All.
+func ParseVulnerable(vulnerable string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(vulnerable)) {
+		case "NONE", "LOVE", "-":
+			return "None", nil
+		case "NS":
+			return "NS", nil
+		case "EW":
+			return "EW", nil
+		case "ALL", "BOTH":
+			return "All", nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidVulnerable, vulnerable)
+}
+
+// ParseDeal reads a Deal tag and returns the hands of North, East, South and
+// West, each written spades.hearts.diamonds.clubs with the cards high to low.
+func ParseDeal(deal string) ([4]string, error) {
+	var hands [4]string
+	deal = strings.TrimSpace(deal)
+	if len(deal) < 2 || deal[1] != ':' {
+		return hands, fmt.Errorf("%w: %q", ErrInvalidDeal, deal)
+	}
+	first := strings.Index("NESW", strings.ToUpper(deal[:1]))
+	parts := strings.Fields(deal[2:])
+	if first < 0 || len(parts) != 4 {
+		return hands, fmt.Errorf("%w: %q", ErrInvalidDeal, deal)
+	}
+
+	dealt := make(map[string]bool)
+	for i, part := range parts {
+		hand, cards, err := parseHand(part)
+		if err != nil {
+			return hands, err
+		}
+		if len(cards) != 13 {
+			return hands, fmt.Errorf("%w: %s holds %d cards", ErrIncompleteDeal, Seats[(first+i)%4], len(cards))
+		}
+		for _, card := range cards {
+			if dealt[card] {
+				return hands, fmt.Errorf("%w: %s is dealt twice", ErrIncompleteDeal, card)
+			}
+			dealt[card] = true
+		}
+		hands[(first+i)%4] = hand
+	}
+	return hands, nil
+}
+
+// Returns the hand with each suit sorted, and its cards as suit and rank.
+func parseHand(hand string) (string, []string, error) {
+	suits := strings.Split(strings.ToUpper(hand), ".")
+	if len(suits) != 4 {
+		return "", nil, fmt.Errorf("%w: %q", ErrInvalidHand, hand)
+	}
+	var cards []string
+	for i, suit := range suits {
+		suit = strings.ReplaceAll(suit, "10", "T")
+		var sorted strings.Builder
+		for _, rank := range ranks {
+			if strings.ContainsRune(suit, rank) {
+				sorted.WriteRune(rank)
+				cards = append(cards, string("SHDC"[i])+string(rank))
+			}
+		}
+		if sorted.Len() != len(suit) {
+			return "", nil, fmt.Errorf("%w: %q", ErrInvalidCard, hand)
+		}
+		suits[i] = sorted.String()
+	}
+	return strings.Join(suits, "."), cards, nil
+}
+
+// One row of a ScoreTable. Scores are each side's own, so NS 420 is EW -420.
+type ScoreLine struct {
+	NSPairId      string
+	EWPairId      string
+	Contract      string //blank on a director's score
+	Declarer      string //N, E, S or W, blank when only the declaring side is known
+	Tricks        int
+	NSScore       int
+	EWScore       int
+	NSMatchpoints float64
+	EWMatchpoints float64
+	NSPercentage  float64
+	EWPercentage  float64
+	NSIMPs        float64
+	EWIMPs        float64
+}
+
+type Game struct {
+	BoardNumber int
+	Dealer      string
+	Vulnerable  string
+	Deal        *types.Deal //the Deal tag is left out when the hands are not known
+	Scores      []ScoreLine
+}
+
+type Session struct {
+	Event string
+	Site  string
+	Date  string //YYYY.MM.DD
+	IMPs  bool   //score tables carry IMPs instead of matchpoints
+	Games []Game
+}
+
+// FormatDeal writes the hands as a Deal tag value starting from North.
+func FormatDeal(deal types.Deal) string {
+	return fmt.Sprintf("N:%s %s %s %s", deal.North, deal.East, deal.South, deal.West)
+}
+
+func tagValue(value string) string {
+	if value == "" {
+		return "?"
+	}
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Write exports a session, one game per board with its ScoreTable.
+func Write(w io.Writer, session Session) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "% PBN 2.1")
+	fmt.Fprintln(out, "% EXPORT")
+	fmt.Fprintln(out)
+
+	for _, game := range session.Games {
+		fmt.Fprintf(out, "[Event \"%s\"]\n", tagValue(session.Event))
+		fmt.Fprintf(out, "[Site \"%s\"]\n", tagValue(session.Site))
+		fmt.Fprintf(out, "[Date \"%s\"]\n", tagValue(session.Date))
+		fmt.Fprintf(out, "[Board \"%d\"]\n", game.BoardNumber)
+		fmt.Fprintf(out, "[Dealer \"%s\"]\n", tagValue(game.Dealer))
+		fmt.Fprintf(out, "[Vulnerable \"%s\"]\n", tagValue(game.Vulnerable))
+		if game.Deal != nil {
+			fmt.Fprintf(out, "[Deal \"%s\"]\n", FormatDeal(*game.Deal))
+		}
+		if len(game.Scores) > 0 {
+			writeScoreTable(out, game.Scores, session.IMPs)
+		}
+		fmt.Fprintln(out)
+	}
+	return out.Flush()
+}
+
+func writeScoreTable(out io.Writer, scores []ScoreLine, imps bool) {
+	columns := []string{"PairId_NS", "PairId_EW", "Contract", "Declarer", "Result", "Score_NS", "Score_EW"}
+	if imps {
+		columns = append(columns, "IMP_NS", "IMP_EW")
+	} else {
+		columns = append(columns, "MP_NS", "MP_EW", "Percentage_NS", "Percentage_EW")
+	}
+
+	rows := make([][]string, len(scores))
+	for i, score := range scores {
+		//every cell needs a token, so what is not known is written as -
+		contract, declarer, result := "-", "-", "-"
+		switch {
+			case strings.EqualFold(score.Contract, scoring.PassedOut):
+				contract = "Pass"
+			case score.Contract != "":
+				contract, result = score.Contract, strconv.Itoa(score.Tricks)
+				if score.Declarer != "" {
+					declarer = score.Declarer
+				}
+		}
+		row := []string{score.NSPairId, score.EWPairId, contract, declarer, result, strconv.Itoa(score.NSScore), strconv.Itoa(score.EWScore)}
+		if imps {
+			row = append(row, formatFloat(score.NSIMPs), formatFloat(score.EWIMPs))
+		} else {
+			row = append(row, formatFloat(score.NSMatchpoints), formatFloat(score.EWMatchpoints),
+				formatFloat(score.NSPercentage), formatFloat(score.EWPercentage))
+		}
+		rows[i] = row
+	}
+
+	//columns are as wide as their widest value, text on the left and numbers on the right
+	definitions := make([]string, len(columns))
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		for _, row := range rows {
+			widths[i] = max(widths[i], len(row[i]))
+		}
+		align := "R"
+		if column == "Contract" || column == "Declarer" {
+			align = "L"
+		}
+		definitions[i] = fmt.Sprintf("%s\\%d%s", column, widths[i], align)
+	}
+	fmt.Fprintf(out, "[ScoreTable \"%s\"]\n", strings.Join(definitions, ";"))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			if strings.HasSuffix(definitions[i], "L") {
+				cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+			} else {
+				cells[i] = fmt.Sprintf("%*s", widths[i], cell)
+			}
+		}
+		fmt.Fprintln(out, strings.Join(cells, " "))
+	}
+}
@@ -0,0 +1,106 @@
+package pbn
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"src/types"
+	"strings"
+	"testing"
+)
+
+func parseFile(t *testing.T, path string) []types.Deal {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	deals, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", path, err)
+	}
+	return deals
+}
+
+func TestParse(t *testing.T) {
+	deals := parseFile(t, "testdata/session.pbn")
+	want := []types.Deal{
+		{
+			BoardNumber: 1, Dealer: "N", Vulnerability: "None",
+			North: "AKQJ.AKQ.AKQ.AKQ", East: "T987.JT9.JT9.JT9", South: "6543.876.876.876", West: "2.5432.5432.5432",
+		},
+		{
+			BoardNumber: 2, Dealer: "E", Vulnerability: "NS",
+			North: "9.Q987.QT6.KQJ97", East: "A63.AJ632.A95.83", South: "QJT854.K5.72.A54", West: "K72.T4.KJ843.T62",
+		},
+		{
+			BoardNumber: 3, Dealer: "S", Vulnerability: "All",
+			North: "A8.AJ.AKQ65.A982", East: "Q2.K5432.J9.QT74", South: "KT765.Q976.84.J3", West: "J943.T8.T732.K65",
+		},
+	}
+	if !reflect.DeepEqual(deals, want) {
+		t.Errorf("Parse =\n%+v\nwant\n%+v", deals, want)
+	}
+}
+
+// Writing the boards of a file with their results and reading them back
+// gives the same deals, and every ScoreTable row has a token per column.
+func TestWriteRoundTrip(t *testing.T) {
+	deals := parseFile(t, "testdata/session.pbn")
+	scores := map[int][]ScoreLine{
+		1: {
+			{NSPairId: "1", EWPairId: "4", Contract: "7NT", Declarer: "N", Tricks: 13, NSScore: 1520, EWScore: -1520, NSMatchpoints: 1.5, EWMatchpoints: 0.5, NSPercentage: 75, EWPercentage: 25},
+			{NSPairId: "2", EWPairId: "3", Contract: "6NT", Declarer: "S", Tricks: 12, NSScore: 990, EWScore: -990, NSMatchpoints: 0.5, EWMatchpoints: 1.5, NSPercentage: 25, EWPercentage: 75},
+		},
+		3: {
+			{NSPairId: "1", EWPairId: "4", Contract: "PASS", NSMatchpoints: 0, EWMatchpoints: 2, NSPercentage: 0, EWPercentage: 100},
+			{NSPairId: "2", EWPairId: "3", Contract: "4H", Declarer: "N", Tricks: 10, NSScore: 620, EWScore: -620, NSMatchpoints: 2, EWMatchpoints: 0, NSPercentage: 100, EWPercentage: 0},
+		},
+	}
+	session := Session{Event: "Tuesday Pairs", Date: "2026.10.13"}
+	for i := range deals {
+		session.Games = append(session.Games, Game{
+			BoardNumber: deals[i].BoardNumber,
+			Dealer:      deals[i].Dealer,
+			Vulnerable:  deals[i].Vulnerability,
+			Deal:        &deals[i],
+			Scores:      scores[deals[i].BoardNumber],
+		})
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, session); err != nil {
+		t.Fatal(err)
+	}
+	written := out.String()
+	back, err := Parse(strings.NewReader(written))
+	if err != nil {
+		t.Fatalf("Parse of written session: %v\n%s", err, written)
+	}
+	if !reflect.DeepEqual(back, deals) {
+		t.Errorf("read back\n%+v\nwant\n%+v", back, deals)
+	}
+
+	columns := 0
+	rows := map[string]bool{}
+	for _, line := range strings.Split(written, "\n") {
+		switch {
+			case strings.HasPrefix(line, "[ScoreTable "):
+				columns = strings.Count(line, ";") + 1
+			case line == "" || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "%"):
+				columns = 0
+			case columns > 0:
+				fields := strings.Fields(line)
+				if len(fields) != columns {
+					t.Errorf("row %q has %d tokens, want %d", line, len(fields), columns)
+				}
+				rows[strings.Join(fields[:5], " ")] = true
+		}
+	}
+	for _, row := range []string{"1 4 7NT N 13", "2 3 6NT S 12", "1 4 Pass - -", "2 3 4H N 10"} {
+		if !rows[row] {
+			t.Errorf("no ScoreTable row starting %q in\n%s", row, written)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package scoring
+
+import (
+	"fmt"
+	"src/types"
+)
+
+const (
+	AdjustmentArtificial = "Artificial"
+	AdjustmentAssigned   = "Assigned"
+)
+
+const (
+	AveragePlus  = "A+"
+	Average      = "A="
+	AverageMinus = "A-"
+)
+
+// ArtificialPercentages is what each artificial adjusted score is worth in a
+// matchpoint event.
+type ArtificialPercentages struct {
+	Plus  float64
+	Even  float64
+	Minus float64
+}
+
+var DefaultArtificialPercentages = ArtificialPercentages{Plus: 60, Even: 50, Minus: 40}
+
+// IMPs for an artificial adjusted score in an IMP event.
+const artificialIMPs = 3
+
+func (p ArtificialPercentages) percentage(adjustment string) (float64, error) {
+	switch adjustment {
+		case AveragePlus:
+			return p.Plus, nil
+		case Average:
+			return p.Even, nil
+		case AverageMinus:
+			return p.Minus, nil
+		default:
+			return 0, fmt.Errorf("unknown artificial score %q", adjustment)
+	}
+}
+
+func ArtificialIMPs(adjustment string) (float64, error) {
+	switch adjustment {
+		case AveragePlus:
+			return artificialIMPs, nil
+		case Average:
+			return 0, nil
+		case AverageMinus:
+			return -artificialIMPs, nil
+		default:
+			return 0, fmt.Errorf("unknown artificial score %q", adjustment)
+	}
+}
+
+func ValidateArtificial(adjustment string) error {
+	_, err := ArtificialIMPs(adjustment)
+	return err
+}
+
+// SplitArtificial separates artificial adjusted scores, which are not
+// compared with the field, from the results that were played or assigned.
+func SplitArtificial(results []types.BoardResult) ([]types.BoardResult, []types.BoardResult) {
+	var played, artificial []types.BoardResult
+	for _, res := range results {
+		if res.Adjustment == AdjustmentArtificial {
+			artificial = append(artificial, res)
+		} else {
+			played = append(played, res)
+		}
+	}
+	return played, artificial
+}
+
+// CalculateArtificialMatchpoints gives each side of an artificial adjusted
+// score its percentage of the board top.
+func CalculateArtificialMatchpoints(results []types.BoardResult, top float64, percentages ArtificialPercentages) (map[string]types.MatchpointScore, error) {
+	mpScores := make(map[string]types.MatchpointScore)
+	for _, res := range results {
+		nsPct, err := percentages.percentage(res.NSArtificial)
+		if err != nil {
+			return nil, err
+		}
+		ewPct, err := percentages.percentage(res.EWArtificial)
+		if err != nil {
+			return nil, err
+		}
+		mpScores[res.NSPairId] = artificialScore(res, res.NSPairId, "NS", res.NSArtificial, nsPct/100*top, 0)
+		mpScores[res.EWPairId] = artificialScore(res, res.EWPairId, "EW", res.EWArtificial, ewPct/100*top, 0)
+	}
+	return mpScores, nil
+}
+
+// CalculateArtificialIMPs is the IMP counterpart of
+// CalculateArtificialMatchpoints: A+ is worth 3 IMPs and A- costs 3.
+func CalculateArtificialIMPs(results []types.BoardResult) (map[string]types.MatchpointScore, error) {
+	impScores := make(map[string]types.MatchpointScore)
+	for _, res := range results {
+		nsIMPs, err := ArtificialIMPs(res.NSArtificial)
+		if err != nil {
+			return nil, err
+		}
+		ewIMPs, err := ArtificialIMPs(res.EWArtificial)
+		if err != nil {
+			return nil, err
+		}
+		impScores[res.NSPairId] = artificialScore(res, res.NSPairId, "NS", res.NSArtificial, 0, nsIMPs)
+		impScores[res.EWPairId] = artificialScore(res, res.EWPairId, "EW", res.EWArtificial, 0, ewIMPs)
+	}
+	return impScores, nil
+}
+
+func artificialScore(res types.BoardResult, pairId string, direction string, adjustment string, mps float64, imps float64) types.MatchpointScore {
+	fmt.Printf("Artificial score %s for %s on board %d\n", adjustment, pairId, res.BoardNumber)
+	return types.MatchpointScore{
+		PairID:    pairId,
+		Direction: direction,
+		MPScore:   mps,
+		IMPs:      imps,
+		Result:    adjustment,
+	}
+}
+
+// GroupByFoul splits the results of a fouled board into the groups that
+// played the same deal. Results not marked as fouled are group 0.
+func GroupByFoul(results []types.BoardResult) [][]types.BoardResult {
+	var groups [][]types.BoardResult
+	index := make(map[int]int)
+	for _, res := range results {
+		i, ok := index[res.FoulGroup]
+		if !ok {
+			i = len(groups)
+			index[res.FoulGroup] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], res)
+	}
+	return groups
+}
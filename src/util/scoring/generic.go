@@ -1,31 +1,133 @@
 package scoring
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 )
 
-func CalculateScore(contract string, direction string, result string, generalVul int) int {
-	// Example contract: "4HX" or "3NTXX"
-	level, _ := strconv.Atoi(string(contract[0]))
-	rest := contract[1:]
-	vul := 0
+var (
+	ErrEmptyContract       = errors.New("contract is empty")
+	ErrInvalidLevel        = errors.New("contract level must be 1 to 7")
+	ErrInvalidDenomination = errors.New("denomination must be C, D, H, S or NT")
+	ErrInvalidDouble       = errors.New("only X or XX may follow the denomination")
+	ErrInvalidDirection    = errors.New("declarer direction must be NS or EW")
+	ErrInvalidResult       = errors.New("result must be =, +n, -n or the number of tricks over book")
+	ErrImpossibleTricks    = errors.New("declarer must take between 0 and 13 tricks")
+)
 
-	if generalVul == 3 || (generalVul == 1 && direction == "NS") || (generalVul == 2 && direction == "EW"){
-		vul = 1
+const PassedOut = "PASS"
+
+type Contract struct {
+	Level        int
+	Denomination string //C, D, H, S or NT
+	Doubled      string //"", "X" or "XX"
+	PassedOut    bool
+}
+
+// ParseContract reads a contract such as "4HX" or "3NTXX", or "PASS" for a
+// passed-out board.
+func ParseContract(contract string) (Contract, error) {
+	contract = strings.ToUpper(strings.TrimSpace(contract))
+	if contract == "" {
+		return Contract{}, ErrEmptyContract
+	}
+	if contract == PassedOut {
+		return Contract{PassedOut: true}, nil
 	}
-	
+
+	level, err := strconv.Atoi(contract[:1])
+	if err != nil || level < 1 || level > 7 {
+		return Contract{}, fmt.Errorf("%w: %q", ErrInvalidLevel, contract)
+	}
+	rest := contract[1:]
 
 	// Extract denomination and double/redouble
-	denom := ""
-	doubleType := "" // "", "X", "XX"
-
-	for _, r := range []string{"NT", "S", "H", "D", "C"} {
-		if strings.HasPrefix(rest, r) {
-			denom = r
-			doubleType = strings.TrimPrefix(rest, r)
-			break
+	for _, denom := range []string{"NT", "S", "H", "D", "C"} {
+		if strings.HasPrefix(rest, denom) {
+			doubled := strings.TrimPrefix(rest, denom)
+			if doubled != "" && doubled != "X" && doubled != "XX" {
+				return Contract{}, fmt.Errorf("%w: %q", ErrInvalidDouble, contract)
+			}
+			return Contract{Level: level, Denomination: denom, Doubled: doubled}, nil
+		}
+	}
+	return Contract{}, fmt.Errorf("%w: %q", ErrInvalidDenomination, contract)
+}
+
+// ParseResult returns how many tricks over or under the contract declarer
+// took. The result is "=", "+n", "-n" or the tricks over book.
+func ParseResult(contract Contract, result string) (int, error) {
+	result = strings.TrimSpace(result)
+	overUnder := 0
+	if result == "=" {
+		overUnder = 0
+	} else if strings.HasPrefix(result, "+") || strings.HasPrefix(result, "-") {
+		num, err := strconv.Atoi(result[1:])
+		if err != nil || num < 1 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidResult, result)
+		}
+		overUnder = num
+		if result[0] == '-' {
+			overUnder = -num
 		}
+	} else {
+		num, err := strconv.Atoi(result)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidResult, result)
+		}
+		overUnder = num - contract.Level
+	}
+
+	totalTricks := contract.Level + 6 + overUnder
+	if totalTricks < 0 || totalTricks > 13 {
+		return 0, fmt.Errorf("%w: %d%s %s is %d tricks", ErrImpossibleTricks, contract.Level, contract.Denomination, result, totalTricks)
+	}
+	return overUnder, nil
+}
+
+// Tricks returns how many tricks declarer took, 0 on a passed-out board.
+func Tricks(contract string, result string) (int, error) {
+	parsed, err := ParseContract(contract)
+	if err != nil {
+		return 0, err
+	}
+	if parsed.PassedOut {
+		return 0, nil
+	}
+	overUnder, err := ParseResult(parsed, result)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Level + 6 + overUnder, nil
+}
+
+// CalculateScore scores a contract for the declaring side. A passed-out
+// board scores 0.
+func CalculateScore(contract string, direction string, result string, generalVul int) (int, error) {
+	parsed, err := ParseContract(contract)
+	if err != nil {
+		return 0, err
+	}
+	if parsed.PassedOut {
+		return 0, nil
+	}
+	if direction != "NS" && direction != "EW" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDirection, direction)
+	}
+	overUnder, err := ParseResult(parsed, result)
+	if err != nil {
+		return 0, err
+	}
+
+	level := parsed.Level
+	denom := parsed.Denomination
+	doubleType := parsed.Doubled
+	vul := 0
+
+	if generalVul == 3 || (generalVul == 1 && direction == "NS") || (generalVul == 2 && direction == "EW"){
+		vul = 1
 	}
 
 	// Trick point values
@@ -46,25 +148,7 @@ func CalculateScore(contract string, direction string, result string, generalVul
 		multiplier = 4
 	}
 
-	// Parse result
-	overUnder := 0
 	contractTricks := level + 6
-	result = strings.TrimSpace(result)
-	if result == "=" {
-		overUnder = 0
-	} else if strings.HasPrefix(result, "+") || strings.HasPrefix(result, "-") {
-		sign := result[0]
-		num, _ := strconv.Atoi(result[1:])
-		if sign == '+' {
-			overUnder = num
-		} else if sign == '-' {
-			overUnder = -num
-		} 
-	} else {
-		num, _ := strconv.Atoi(result)
-		overUnder = num - level
-	}
-
 	totalTricks := contractTricks + overUnder
 
 	score := 0
@@ -82,8 +166,10 @@ func CalculateScore(contract string, direction string, result string, generalVul
 		score += trickPoints
 
 		// Bonuses
-		if multiplier > 1 {
-			score += 50 // insult bonus for (re)doubled
+		if multiplier == 2 {
+			score += 50 // insult bonus for doubled
+		} else if multiplier == 4 {
+			score += 100 // and for redoubled
 		}
 
 		// Game or part-score
@@ -148,7 +234,8 @@ func CalculateScore(contract string, direction string, result string, generalVul
 				} else if tricksDown == 2 {
 					base = 100 + 200
 				} else if tricksDown >= 3 {
-					base = 100 + 200 + (tricksDown-2)*300
+					//the second and third cost 200 each, the rest 300
+					base = 500 + (tricksDown-3)*300
 				}
 			} else {
 				if tricksDown == 1 {
@@ -165,6 +252,5 @@ func CalculateScore(contract string, direction string, result string, generalVul
 		}
 	}
 
-	return score
+	return score, nil
 }
-
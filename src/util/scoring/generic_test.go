@@ -0,0 +1,46 @@
+package scoring
+
+import (
+	"src/util/boards"
+	"testing"
+)
+
+func TestCalculateScore(t *testing.T) {
+	tests := []struct {
+		contract   string
+		direction  string
+		result     string
+		vulnerable int
+		want       int
+	}{
+		{"PASS", "", "", boards.VulAll, 0},
+		{"1NT", "NS", "=", boards.VulNone, 90},
+		{"3NT", "EW", "+1", boards.VulEW, 630},
+		{"4S", "NS", "-2", boards.VulNS, -200},
+		{"6H", "NS", "=", boards.VulNone, 980},
+		{"7NT", "EW", "=", boards.VulAll, 2220},
+		{"2HX", "NS", "=", boards.VulNone, 470},
+		{"1NTXX", "NS", "=", boards.VulNone, 560},
+		{"1NTXX", "NS", "=", boards.VulNS, 760},
+		//doubled not vulnerable: 100, 300, 500, then 300 more each
+		{"4SX", "EW", "-1", boards.VulNone, -100},
+		{"4SX", "EW", "-2", boards.VulNone, -300},
+		{"4SX", "EW", "-3", boards.VulNone, -500},
+		{"4SX", "EW", "-4", boards.VulNone, -800},
+		{"4SX", "EW", "-5", boards.VulNone, -1100},
+		{"4SXX", "EW", "-3", boards.VulNone, -1000},
+		//doubled vulnerable: 200, then 300 more each
+		{"4SX", "EW", "-1", boards.VulEW, -200},
+		{"4SX", "EW", "-3", boards.VulEW, -800},
+	}
+	for _, test := range tests {
+		got, err := CalculateScore(test.contract, test.direction, test.result, test.vulnerable)
+		if err != nil {
+			t.Errorf("CalculateScore(%s %s %s): %v", test.contract, test.direction, test.result, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("CalculateScore(%s %s %s, vul %d) = %d, want %d", test.contract, test.direction, test.result, test.vulnerable, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"src/types"
+)
+
+// Upper bound of each band of the WBF IMP scale; the IMP value of a
+// difference is the number of bands it exceeds.
+var impScale = []int{
+	10, 40, 80, 120, 160, 210, 260, 310, 360, 420, 490, 590,
+	740, 890, 1090, 1290, 1490, 1740, 1990, 2240, 2490, 2990, 3490, 3990,
+}
+
+// IMPsForDifference converts a point difference into IMPs, keeping its sign.
+func IMPsForDifference(diff int) int {
+	abs := diff
+	if abs < 0 {
+		abs = -abs
+	}
+	imps := 0
+	for _, bound := range impScale {
+		if abs > bound {
+			imps++
+		}
+	}
+	if diff < 0 {
+		return -imps
+	}
+	return imps
+}
+
+// DefaultButlerTrim returns how many results to drop from each end of the
+// field before averaging: one for five or more results, none otherwise.
+func DefaultButlerTrim(n int) int {
+	if n >= 5 {
+		return 1
+	}
+	return 0
+}
+
+// ButlerDatum averages the NS scores after dropping the top and bottom
+// trim results and rounds to the nearest 10.
+func ButlerDatum(scores []int, trim int) int {
+	sorted := append([]int(nil), scores...)
+	sort.Ints(sorted)
+	if trim < 0 || 2*trim >= len(sorted) {
+		trim = 0
+	}
+	sorted = sorted[trim : len(sorted)-trim]
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, s := range sorted {
+		total += s
+	}
+	avg := float64(total) / float64(len(sorted))
+	return int(math.Round(avg/10) * 10)
+}
+
+// CalculateCrossIMPs compares every NS score on a board with every other
+// one. The IMPs are averaged over the number of comparisons so boards played
+// a different number of times stay on the same scale.
+func CalculateCrossIMPs(results []types.BoardResult) map[string]types.MatchpointScore {
+	impScores := make(map[string]types.MatchpointScore)
+	n := len(results)
+
+	for i, res := range results {
+		imps := 0.0
+		ewIMPs := 0.0
+		if n > 1 {
+			total := 0
+			ewTotal := 0
+			for j, other := range results {
+				if i == j {
+					continue
+				}
+				total += IMPsForDifference(res.Score - other.Score)
+				ewTotal += IMPsForDifference(EWScore(other) - EWScore(res))
+			}
+			imps = float64(total) / float64(n-1)
+			ewIMPs = float64(ewTotal) / float64(n-1)
+		}
+		addIMPScores(impScores, res, imps, ewIMPs, 0)
+	}
+
+	return impScores
+}
+
+// CalculateButler scores each NS result against the trimmed average of the
+// field (the datum).
+func CalculateButler(results []types.BoardResult, trim int) map[string]types.MatchpointScore {
+	impScores := make(map[string]types.MatchpointScore)
+
+	var scores []int
+	for _, res := range results {
+		scores = append(scores, res.Score)
+	}
+	datum := ButlerDatum(scores, trim)
+
+	for _, res := range results {
+		imps := float64(IMPsForDifference(res.Score - datum))
+		ewIMPs := float64(IMPsForDifference(datum - EWScore(res)))
+		addIMPScores(impScores, res, imps, ewIMPs, datum)
+	}
+
+	return impScores
+}
+
+func addIMPScores(impScores map[string]types.MatchpointScore, res types.BoardResult, imps float64, ewIMPs float64, datum int) {
+	impScores[res.NSPairId] = types.MatchpointScore{
+		PairID:            res.NSPairId,
+		Direction:         "NS",
+		RawScore:          res.Score,
+		IMPs:              imps,
+		Datum:             datum,
+		Contract:          res.Contract,
+		ContractDirection: res.Direction,
+		Result:            res.Result,
+	}
+	impScores[res.EWPairId] = types.MatchpointScore{
+		PairID:            res.EWPairId,
+		Direction:         "EW",
+		RawScore:          -EWScore(res),
+		IMPs:              ewIMPs,
+		Datum:             -datum,
+		Contract:          res.Contract,
+		ContractDirection: res.Direction,
+		Result:            res.Result,
+	}
+	fmt.Printf("IMP scores %s: raw=%d ,imps=%f\n", res.NSPairId, res.Score, imps)
+}
@@ -0,0 +1,135 @@
+package scoring
+
+import (
+	"src/types"
+	"testing"
+)
+
+func TestIMPsForDifference(t *testing.T) {
+	//each step of the scale at both ends
+	tests := []struct {
+		diff int
+		want int
+	}{
+		{0, 0}, {10, 0},
+		{20, 1}, {40, 1},
+		{50, 2}, {80, 2},
+		{90, 3}, {120, 3},
+		{130, 4}, {160, 4},
+		{170, 5}, {210, 5},
+		{220, 6}, {260, 6},
+		{270, 7}, {310, 7},
+		{320, 8}, {360, 8},
+		{370, 9}, {420, 9},
+		{430, 10}, {490, 10},
+		{500, 11}, {590, 11},
+		{600, 12}, {740, 12},
+		{750, 13}, {890, 13},
+		{900, 14}, {1090, 14},
+		{1100, 15}, {1290, 15},
+		{1300, 16}, {1490, 16},
+		{1500, 17}, {1740, 17},
+		{1750, 18}, {1990, 18},
+		{2000, 19}, {2240, 19},
+		{2250, 20}, {2490, 20},
+		{2500, 21}, {2990, 21},
+		{3000, 22}, {3490, 22},
+		{3500, 23}, {3990, 23},
+		{4000, 24}, {7600, 24},
+	}
+	for _, test := range tests {
+		if got := IMPsForDifference(test.diff); got != test.want {
+			t.Errorf("IMPsForDifference(%d) = %d, want %d", test.diff, got, test.want)
+		}
+		if got := IMPsForDifference(-test.diff); got != -test.want {
+			t.Errorf("IMPsForDifference(%d) = %d, want %d", -test.diff, got, -test.want)
+		}
+	}
+}
+
+func TestDefaultButlerTrim(t *testing.T) {
+	for n, want := range map[int]int{0: 0, 1: 0, 4: 0, 5: 1, 12: 1} {
+		if got := DefaultButlerTrim(n); got != want {
+			t.Errorf("DefaultButlerTrim(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestButlerDatum(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []int
+		trim   int
+		want   int
+	}{
+		{"no results", nil, 0, 0},
+		{"untrimmed", []int{420, 450, -50, 420}, 0, 310},
+		{"top and bottom dropped", []int{1430, 420, 450, -100, 420}, 1, 430},
+		{"two dropped from each end", []int{2000, 1430, 420, 450, -100, -800, 420}, 2, 430},
+		{"trim larger than the field", []int{620, -100}, 1, 260},
+		{"negative trim", []int{100, 140}, -1, 120},
+		{"rounded to the nearest 10", []int{100, 110, 110}, 0, 110},
+		{"rounded away from zero", []int{-100, -110}, 0, -110},
+	}
+	for _, test := range tests {
+		if got := ButlerDatum(test.scores, test.trim); got != test.want {
+			t.Errorf("%s: ButlerDatum(%v, %d) = %d, want %d", test.name, test.scores, test.trim, got, test.want)
+		}
+	}
+}
+
+func boardResult(nsPairId string, ewPairId string, score int) types.BoardResult {
+	return types.BoardResult{NSPairId: nsPairId, EWPairId: ewPairId, Score: score}
+}
+
+func TestCalculateCrossIMPs(t *testing.T) {
+	results := []types.BoardResult{
+		boardResult("1NS", "1EW", 420),
+		boardResult("2NS", "2EW", 170),
+		boardResult("3NS", "3EW", -50),
+	}
+	scores := CalculateCrossIMPs(results)
+
+	//420 is 6 IMPs over 170 and 10 over -50, 170 is 6 over -50
+	want := map[string]float64{
+		"1NS": 8, "1EW": -8,
+		"2NS": 0, "2EW": 0,
+		"3NS": -8, "3EW": 8,
+	}
+	for pairId, imps := range want {
+		if scores[pairId].IMPs != imps {
+			t.Errorf("%s: %v IMPs, want %v", pairId, scores[pairId].IMPs, imps)
+		}
+	}
+
+	lone := CalculateCrossIMPs(results[:1])
+	if lone["1NS"].IMPs != 0 || lone["1EW"].IMPs != 0 {
+		t.Errorf("a lone result scored %v and %v IMPs", lone["1NS"].IMPs, lone["1EW"].IMPs)
+	}
+}
+
+func TestCalculateButler(t *testing.T) {
+	results := []types.BoardResult{
+		boardResult("1NS", "1EW", 1430),
+		boardResult("2NS", "2EW", 420),
+		boardResult("3NS", "3EW", 450),
+		boardResult("4NS", "4EW", -100),
+		boardResult("5NS", "5EW", 420),
+	}
+	//1430 and -100 are dropped, leaving a datum of 430
+	scores := CalculateButler(results, DefaultButlerTrim(len(results)))
+
+	want := map[string]float64{
+		"1NS": 14, "2NS": 0, "3NS": 1, "4NS": -11, "5NS": 0,
+		"1EW": -14, "2EW": 0, "3EW": -1, "4EW": 11, "5EW": 0,
+	}
+	for pairId, imps := range want {
+		score := scores[pairId]
+		if score.IMPs != imps {
+			t.Errorf("%s: %v IMPs, want %v", pairId, score.IMPs, imps)
+		}
+		if score.Direction == "NS" && score.Datum != 430 || score.Direction == "EW" && score.Datum != -430 {
+			t.Errorf("%s: datum %d", pairId, score.Datum)
+		}
+	}
+}
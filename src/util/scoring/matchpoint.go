@@ -18,21 +18,25 @@ type Tournament struct {
 func CalculateMatchpoints(results []types.BoardResult) map[string]types.MatchpointScore {
 	type scoredResult struct {
 		PairID    string
+		EWPairID  string
 		Direction string
 		RawScore  int
+		EWRawScore int
 		Contract string
 		ContractDirection string
 		Result string
 	}
 	var scoredResults []scoredResult
 
-	// Only use NS side as the reference score
+	// Only use NS side as the reference score, EW only differ on a split score
 	for _, res := range results {
 		raw := res.Score
 		scoredResults = append(scoredResults, scoredResult{
 			PairID:    res.NSPairId,
+			EWPairID:  res.EWPairId,
 			Direction: "NS",
 			RawScore:  raw,
+			EWRawScore: EWScore(res),
 			Contract: res.Contract,
 			ContractDirection: res.Direction,
 			Result: res.Result,
@@ -51,14 +55,7 @@ func CalculateMatchpoints(results []types.BoardResult) map[string]types.Matchpoi
 		// Only one result exists; assign 0 matchpoints to both NS and EW
 		nsPair := scoredResults[0].PairID
 		nsScore := scoredResults[0].RawScore
-
-		var ewPair string
-		for _, res := range results {
-			if res.NSPairId == nsPair {
-				ewPair = res.EWPairId
-				break
-			}
-		}
+		ewPair := scoredResults[0].EWPairID
 
 		mpScores[nsPair] = types.MatchpointScore{
 			PairID:    nsPair,
@@ -73,7 +70,7 @@ func CalculateMatchpoints(results []types.BoardResult) map[string]types.Matchpoi
 			PairID:    ewPair,
 			Direction: "EW",
 			MPScore:   0.5,
-			RawScore:  -nsScore,
+			RawScore:  -scoredResults[0].EWRawScore,
 			Contract: scoredResults[0].Contract,
 			ContractDirection: scoredResults[0].ContractDirection,
 			Result: scoredResults[0].Result,
@@ -86,6 +83,7 @@ func CalculateMatchpoints(results []types.BoardResult) map[string]types.Matchpoi
 	// Normal multi-entry matchpoint scoring
 	for i := range scoredResults {
 		mp := 0.0
+		ewMP := 0.0
 		for j := 0; j < n; j++ {
 			if i == j {
 				continue
@@ -95,18 +93,16 @@ func CalculateMatchpoints(results []types.BoardResult) map[string]types.Matchpoi
 			} else if scoredResults[i].RawScore == scoredResults[j].RawScore {
 				mp += 0.5
 			}
+			// EW do better the lower the NS score
+			if scoredResults[i].EWRawScore < scoredResults[j].EWRawScore {
+				ewMP += 1
+			} else if scoredResults[i].EWRawScore == scoredResults[j].EWRawScore {
+				ewMP += 0.5
+			}
 		}
 
 		nsPair := scoredResults[i].PairID
-		ewPair := ""
-
-		// Find matching EW pair from original result
-		for _, res := range results {
-			if res.NSPairId == nsPair {
-				ewPair = res.EWPairId
-				break
-			}
-		}
+		ewPair := scoredResults[i].EWPairID
 
 		// Store MP for NS
 		mpScores[nsPair] = types.MatchpointScore{
@@ -119,13 +115,12 @@ func CalculateMatchpoints(results []types.BoardResult) map[string]types.Matchpoi
 			Result: scoredResults[i].Result,
 		}
 
-		// MP for EW is inverse (max = n - 1)
-		ewMP := float64(n-1) - mp
+		// MP for EW is the inverse (max = n - 1) unless the score was split
 		mpScores[ewPair] = types.MatchpointScore{
 			PairID:    ewPair,
 			Direction: "EW",
 			MPScore:   ewMP,
-			RawScore:  -scoredResults[i].RawScore,
+			RawScore:  -scoredResults[i].EWRawScore,
 			Contract: scoredResults[i].Contract,
 			ContractDirection: scoredResults[i].ContractDirection,
 			Result: scoredResults[i].Result,
@@ -137,6 +132,11 @@ func CalculateMatchpoints(results []types.BoardResult) map[string]types.Matchpoi
 	return mpScores
 }
 
-
-
-
+// EWScore is the NS point of view score EW are ranked on, which only
+// differs from Score on a split assigned score.
+func EWScore(res types.BoardResult) int {
+	if res.Adjustment == AdjustmentAssigned {
+		return res.EWScore
+	}
+	return res.Score
+}
@@ -0,0 +1,29 @@
+package scoring
+
+import (
+	"fmt"
+	"src/types"
+)
+
+// NeubergFactor scales a matchpoint score earned against compared results
+// (top compared-1) to what it would be worth against expected results (top
+// expected-1). A lone result counts as an average.
+func NeubergFactor(mps float64, compared int, expected int) float64 {
+	if compared <= 1 {
+		return float64(expected-1) / 2
+	}
+	return (mps+0.5)*float64(expected)/float64(compared) - 0.5
+}
+
+// ApplyNeuberg factors every score on a board that was scored against
+// compared results up to the top for expected results.
+func ApplyNeuberg(mpScores map[string]types.MatchpointScore, compared int, expected int) map[string]types.MatchpointScore {
+	factored := make(map[string]types.MatchpointScore)
+	for pairId, score := range mpScores {
+		mps := NeubergFactor(score.MPScore, compared, expected)
+		fmt.Printf("Neuberg %s: mps=%f -> %f (%d of %d results)\n", pairId, score.MPScore, mps, compared, expected)
+		score.MPScore = mps
+		factored[pairId] = score
+	}
+	return factored
+}
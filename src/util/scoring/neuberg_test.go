@@ -0,0 +1,45 @@
+package scoring
+
+import (
+	"src/types"
+	"testing"
+)
+
+func TestNeubergFactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		mps      float64
+		compared int
+		expected int
+		want     float64
+	}{
+		{"top of four results on a board for five", 3, 4, 5, 3.875},
+		{"bottom of four results on a board for five", 0, 4, 5, 0.125},
+		{"average stays average", 1.5, 4, 5, 2},
+		{"half the field missing", 2, 3, 6, 4.5},
+		{"every result in", 3, 5, 5, 3},
+		{"a lone result is an average", 0, 1, 5, 2},
+	}
+	for _, test := range tests {
+		if got := NeubergFactor(test.mps, test.compared, test.expected); got != test.want {
+			t.Errorf("%s: NeubergFactor(%v, %d, %d) = %v, want %v", test.name, test.mps, test.compared, test.expected, got, test.want)
+		}
+	}
+}
+
+func TestApplyNeuberg(t *testing.T) {
+	scores := map[string]types.MatchpointScore{
+		"1NS": {PairID: "1NS", Direction: "NS", MPScore: 3, RawScore: 420},
+		"1EW": {PairID: "1EW", Direction: "EW", MPScore: 0, RawScore: -420},
+	}
+	factored := ApplyNeuberg(scores, 4, 5)
+	if factored["1NS"].MPScore != 3.875 || factored["1EW"].MPScore != 0.125 {
+		t.Errorf("factored to %v and %v, want 3.875 and 0.125", factored["1NS"].MPScore, factored["1EW"].MPScore)
+	}
+	if factored["1NS"].RawScore != 420 || factored["1EW"].Direction != "EW" {
+		t.Errorf("factoring changed more than the matchpoints: %+v", factored)
+	}
+	if scores["1NS"].MPScore != 3 {
+		t.Error("ApplyNeuberg changed the scores it was given")
+	}
+}
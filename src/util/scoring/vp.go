@@ -0,0 +1,39 @@
+package scoring
+
+import (
+	"math"
+)
+
+// Golden ratio conjugate used by the WBF continuous VP scale.
+var vpTau = (math.Sqrt(5) - 1) / 2
+
+// VictoryPoints converts an IMP margin over a match of the given length into
+// VPs for both teams on the WBF continuous 20-point scale.
+func VictoryPoints(imps int, boards int) (float64, float64) {
+	if boards <= 0 {
+		return 10, 10
+	}
+	margin := imps
+	if margin < 0 {
+		margin = -margin
+	}
+
+	blitz := 15 * math.Sqrt(float64(boards))
+	winner := 20.0
+	if float64(margin) < blitz {
+		winner = 10 + 10*(1-math.Pow(vpTau, 3*float64(margin)/blitz))/(1-math.Pow(vpTau, 3))
+		winner = math.Min(20, math.Round(winner*100)/100)
+	}
+	loser := math.Round((20-winner)*100) / 100
+
+	if imps < 0 {
+		return loser, winner
+	}
+	return winner, loser
+}
+
+// CompareRooms returns the IMPs won by the team sitting NS in the open room,
+// given the NS scores recorded in the open and closed rooms.
+func CompareRooms(openScore int, closedScore int) int {
+	return IMPsForDifference(openScore - closedScore)
+}
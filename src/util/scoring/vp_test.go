@@ -0,0 +1,46 @@
+package scoring
+
+import "testing"
+
+func TestVictoryPoints(t *testing.T) {
+	tests := []struct {
+		name   string
+		imps   int
+		boards int
+		want   float64
+	}{
+		{"draw", 0, 16, 10},
+		{"one IMP", 1, 16, 10.31},
+		{"close win", 10, 16, 12.8},
+		{"clear win", 30, 16, 16.73},
+		{"one short of the blitz", 59, 16, 19.92},
+		{"blitz", 60, 16, 20},
+		{"blowout", 200, 16, 20},
+		{"shorter match", 10, 12, 13.18},
+		{"blitz of a shorter match", 52, 12, 20},
+		{"one short of the blitz of a longer match", 84, 32, 19.95},
+		{"blitz of a longer match", 85, 32, 20},
+		{"no boards", 30, 0, 10},
+	}
+	for _, test := range tests {
+		winner, loser := VictoryPoints(test.imps, test.boards)
+		if winner != test.want || winner+loser != 20 {
+			t.Errorf("%s: VictoryPoints(%d, %d) = %v, %v, want %v", test.name, test.imps, test.boards, winner, loser, test.want)
+		}
+		//the side that lost the IMPs gets the smaller share
+		loser, winner = VictoryPoints(-test.imps, test.boards)
+		if winner != test.want || winner+loser != 20 {
+			t.Errorf("%s: VictoryPoints(%d, %d) = %v, %v, want %v", test.name, -test.imps, test.boards, loser, winner, 20-test.want)
+		}
+	}
+}
+
+func TestCompareRooms(t *testing.T) {
+	//game made in one room, part score in the other
+	if got := CompareRooms(420, 170); got != 6 {
+		t.Errorf("CompareRooms(420, 170) = %d, want 6", got)
+	}
+	if got := CompareRooms(-100, 620); got != -12 {
+		t.Errorf("CompareRooms(-100, 620) = %d, want -12", got)
+	}
+}
@@ -0,0 +1,97 @@
+package usebio
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// The USEBIO version the documents are written to.
+const Version = "1.2"
+
+// Event types and board scoring methods the federations read.
+const (
+	EventMPPairs       = "MP_PAIRS"
+	EventCrossIMPPairs = "CROSS_IMP_PAIRS"
+	EventButlerPairs   = "BUTLER_PAIRS"
+
+	ScoringMatchPoints = "MATCH_POINTS"
+	ScoringCrossIMPs   = "CROSS_IMPS"
+	ScoringButler      = "BUTLER_IMPS"
+)
+
+// Decimal is written with two places, as scores and percentages are printed.
+type Decimal float64
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatFloat(float64(d), 'f', 2, 64)), nil
+}
+
+type Player struct {
+	Name string `xml:"PLAYER_NAME"`
+}
+
+// Pair is one line of the final ranking.
+type Pair struct {
+	Number     string   `xml:"PAIR_NUMBER"`
+	Direction  string   `xml:"DIRECTION,omitempty"` //NS or EW in a Mitchell
+	Place      string   `xml:"PLACE"`               //1, or 2= on a tie
+	TotalScore Decimal  `xml:"TOTAL_SCORE"`         //matchpoints, or IMPs
+	Percentage *Decimal `xml:"PERCENTAGE,omitempty"`
+	Players    []Player `xml:"PLAYER"`
+}
+
+// TravellerLine is one table's result on a board. Scores are NS's.
+type TravellerLine struct {
+	NSPairNumber  string   `xml:"NS_PAIR_NUMBER"`
+	EWPairNumber  string   `xml:"EW_PAIR_NUMBER"`
+	Contract      string   `xml:"CONTRACT,omitempty"`  //blank on a director's score
+	PlayedBy      string   `xml:"PLAYED_BY,omitempty"` //N, E, S or W, left out when the seat is not known
+	Tricks        *int     `xml:"TRICKS,omitempty"`
+	Score         *int     `xml:"SCORE,omitempty"`      //left out on an artificial score
+	Adjustment    string   `xml:"ADJUSTMENT,omitempty"` //Artificial or Assigned
+	NSMatchPoints *Decimal `xml:"NS_MATCH_POINTS,omitempty"`
+	EWMatchPoints *Decimal `xml:"EW_MATCH_POINTS,omitempty"`
+	NSIMPs        *Decimal `xml:"NS_IMPS,omitempty"`
+	EWIMPs        *Decimal `xml:"EW_IMPS,omitempty"`
+}
+
+type Board struct {
+	Number int             `xml:"BOARD_NUMBER"`
+	Lines  []TravellerLine `xml:"TRAVELLER_LINE"`
+}
+
+type Event struct {
+	Type          string  `xml:"EVENT_TYPE,attr"`
+	Description   string  `xml:"EVENT_DESCRIPTION"`
+	ProgramName   string  `xml:"PROGRAM_NAME"`
+	Date          string  `xml:"DATE,omitempty"` //DD/MM/YYYY
+	ScoringMethod string  `xml:"BOARD_SCORING_METHOD"`
+	WinnerType    int     `xml:"WINNER_TYPE"` //1 for one field, 2 for NS and EW winners
+	Participants  []Pair  `xml:"PARTICIPANTS>PAIR"`
+	Boards        []Board `xml:"BOARD"`
+}
+
+type document struct {
+	XMLName xml.Name `xml:"USEBIO"`
+	Version string   `xml:"Version,attr"`
+	Event   Event    `xml:"EVENT"`
+}
+
+// Write exports one event as a USEBIO document.
+func Write(w io.Writer, event Event) error {
+	if _, err := fmt.Fprint(w, xml.Header); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "<!DOCTYPE USEBIO SYSTEM \"usebio_v%s.dtd\">\n", Version); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document{Version: Version, Event: event}); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}